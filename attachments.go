@@ -2,42 +2,135 @@ package wxr
 
 import (
 	"fmt"
+	"path"
+	"strconv"
 	"strings"
 )
 
+// Attachment is a media library item (post_type="attachment") with its editorial metadata.
+type Attachment struct {
+	// ID is the WordPress attachment ID.
+	ID int
+
+	// ParentID is the ID of the post the attachment was uploaded to, or 0.
+	ParentID int
+
+	// Title is the attachment title.
+	Title string
+
+	// Slug is the URL-friendly attachment name.
+	Slug string
+
+	// Caption is the attachment caption, stored by WordPress as the excerpt.
+	Caption string
+
+	// Description is the attachment description, stored by WordPress as the content.
+	Description string
+
+	// RSSDescription is the description element of the item; see Post.RSSDescription.
+	RSSDescription string
+
+	// AltText is the alternative text of an image (_wp_attachment_image_alt).
+	AltText string
+
+	// MIMEType is the media type of the file, such as "image/jpeg". WXR does not
+	// export it, so it is derived from the file extension; it is empty for file
+	// types WordPress does not accept by default.
+	MIMEType string
+
+	// URL is the absolute URL of the original file.
+	URL string
+
+	// RelativePath is the path of the file below the uploads directory (_wp_attached_file),
+	// such as "2024/01/photo.jpg".
+	RelativePath string
+
+	// Width and Height are the dimensions of an image or video, or 0 when unknown.
+	Width  int
+	Height int
+
+	// FileSize is the size of the original file in bytes, or 0 when unknown.
+	FileSize int64
+
+	// Sizes are the intermediate image sizes generated by WordPress, keyed by size
+	// name such as "thumbnail", "medium" or "large".
+	Sizes map[string]AttachmentSize
+
+	// Date is the upload date in RFC3339 format.
+	Date string
+
+	// LocalDate is the upload date in the site's time zone (wp:post_date), as exported.
+	LocalDate string
+
+	// Meta contains all attachment meta fields as key-value pairs.
+	Meta map[string]string
+
+	// MetaEntries are the meta fields as exported; see Post.MetaEntries.
+	MetaEntries []MetaEntry
+
+	// Extra are the item elements the package does not map, in document order.
+	// See Post.Extra.
+	Extra Nodes
+}
+
+// AttachmentSize is an image size generated by WordPress for an attachment.
+type AttachmentSize struct {
+	// File is the file name of the resized image, in the directory of the original.
+	File string
+
+	// URL is the absolute URL of the resized image.
+	URL string
+
+	// Width and Height are the dimensions of the resized image.
+	Width  int
+	Height int
+
+	// MIMEType is the media type of the resized image.
+	MIMEType string
+}
+
 // AttachmentIndex holds attachment URL mappings for resolving featured images.
 type AttachmentIndex struct {
 	URLsByID     map[int]string
 	URLsByParent map[int][]string
 }
 
-// buildAttachmentIndex builds an index of attachments from WXR items.
-// It creates mappings from attachment IDs to URLs and from parent post IDs to attachment URLs.
-func buildAttachmentIndex(ch channel) *AttachmentIndex {
-	index := &AttachmentIndex{
+// newAttachmentIndex returns an empty AttachmentIndex.
+func newAttachmentIndex() *AttachmentIndex {
+	return &AttachmentIndex{
 		URLsByID:     make(map[int]string),
 		URLsByParent: make(map[int][]string),
 	}
+}
 
+// buildAttachmentIndex builds an index of attachments from WXR items.
+// It creates mappings from attachment IDs to URLs and from parent post IDs to attachment URLs.
+func buildAttachmentIndex(ch channel) *AttachmentIndex {
+	index := newAttachmentIndex()
 	baseUploadsURL := determineUploadsBaseURL(ch)
 
-	for _, item := range ch.Items {
-		if item.PostType != "attachment" {
-			continue
-		}
-
-		url := resolveAttachmentURL(item, baseUploadsURL)
-		if url != "" {
-			index.URLsByID[item.PostID] = url
-			if item.PostParent > 0 {
-				index.URLsByParent[item.PostParent] = append(index.URLsByParent[item.PostParent], url)
-			}
-		}
+	for i := range ch.Items {
+		index.add(&ch.Items[i], baseUploadsURL)
 	}
 
 	return index
 }
 
+// add records an item in the index if it is an attachment with a resolvable URL.
+func (idx *AttachmentIndex) add(item *item, baseUploadsURL string) {
+	if item.PostType != "attachment" {
+		return
+	}
+
+	url := resolveAttachmentURL(*item, baseUploadsURL)
+	if url != "" {
+		idx.URLsByID[item.PostID] = url
+		if item.PostParent > 0 {
+			idx.URLsByParent[item.PostParent] = append(idx.URLsByParent[item.PostParent], url)
+		}
+	}
+}
+
 // resolveAttachmentURL resolves the URL for an attachment item.
 // It tries multiple sources in order: AttachmentURL, Link, GUID, or constructs from _wp_attached_file meta.
 func resolveAttachmentURL(item item, baseUploadsURL string) string {
@@ -77,3 +170,168 @@ func determineUploadsBaseURL(ch channel) string {
 	}
 	return ""
 }
+
+// buildAttachments converts the attachment items of a channel in document order.
+func buildAttachments(ch channel) []Attachment {
+	baseUploadsURL := determineUploadsBaseURL(ch)
+	attachments := make([]Attachment, 0)
+	for i := range ch.Items {
+		if ch.Items[i].PostType == "attachment" {
+			attachments = append(attachments, buildAttachment(&ch.Items[i], baseUploadsURL))
+		}
+	}
+	return attachments
+}
+
+// buildAttachment converts an attachment item, reading dimensions and generated
+// sizes from the serialized _wp_attachment_metadata meta field.
+func buildAttachment(item *item, baseUploadsURL string) Attachment {
+	a := Attachment{
+		ID:             item.PostID,
+		ParentID:       item.PostParent,
+		Title:          item.Title,
+		Slug:           item.PostName,
+		Caption:        item.ExcerptEncoded,
+		Description:    item.ContentEncoded,
+		RSSDescription: item.Description,
+		AltText:        getMetaValue(item.PostMeta, "_wp_attachment_image_alt"),
+		URL:            resolveAttachmentURL(*item, baseUploadsURL),
+		RelativePath:   strings.TrimPrefix(getMetaValue(item.PostMeta, "_wp_attached_file"), "/"),
+		Sizes:          make(map[string]AttachmentSize),
+		LocalDate:      item.PostDate,
+		Meta:           metaMap(item.PostMeta),
+		MetaEntries:    metaEntries(item.PostMeta),
+		Extra:          item.Extra,
+	}
+	if date := item.PostDateGMT; date != "" {
+		a.Date = normalizeWXRDate(date)
+	} else if item.PostDate != "" {
+		a.Date = normalizeWXRDate(item.PostDate)
+	}
+	if a.RelativePath == "" {
+		if i := strings.Index(a.URL, "/wp-content/uploads/"); i >= 0 {
+			a.RelativePath = a.URL[i+len("/wp-content/uploads/"):]
+		}
+	}
+
+	if raw := getMetaValue(item.PostMeta, "_wp_attachment_metadata"); raw != "" {
+		if v, err := UnserializePHP(raw); err == nil {
+			if metadata, ok := v.(map[string]any); ok {
+				a.applyMetadata(metadata)
+			}
+		}
+	}
+
+	file := a.RelativePath
+	if file == "" {
+		file = a.URL
+	}
+	a.MIMEType = mimeTypeByExtension(file)
+	return a
+}
+
+// applyMetadata fills dimensions and sizes from decoded _wp_attachment_metadata.
+func (a *Attachment) applyMetadata(metadata map[string]any) {
+	a.Width, _ = phpInt(metadata["width"])
+	a.Height, _ = phpInt(metadata["height"])
+	if size, ok := phpInt(metadata["filesize"]); ok {
+		a.FileSize = int64(size)
+	}
+	if a.RelativePath == "" {
+		if file, ok := metadata["file"].(string); ok {
+			a.RelativePath = strings.TrimPrefix(file, "/")
+		}
+	}
+
+	sizes, _ := metadata["sizes"].(map[string]any)
+	for name, v := range sizes {
+		fields, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		file, _ := fields["file"].(string)
+		if file == "" {
+			continue
+		}
+		size := AttachmentSize{File: file}
+		size.Width, _ = phpInt(fields["width"])
+		size.Height, _ = phpInt(fields["height"])
+		size.MIMEType, _ = fields["mime-type"].(string)
+		if size.MIMEType == "" {
+			size.MIMEType = mimeTypeByExtension(file)
+		}
+		if a.URL != "" {
+			size.URL = a.URL[:strings.LastIndex(a.URL, "/")+1] + file
+		}
+		a.Sizes[name] = size
+	}
+}
+
+// phpInt converts a decoded PHP integer, or a numeric string, to an int.
+func phpInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// mediaTypes maps the file extensions WordPress accepts by default to media types.
+// The system MIME database is not consulted, so that results are the same on
+// every machine; other extensions have no media type.
+var mediaTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".jpe":  "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".avif": "image/avif",
+	".heic": "image/heic",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+	".bmp":  "image/bmp",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".ogv":  "video/ogg",
+	".avi":  "video/avi",
+	".wmv":  "video/x-ms-wmv",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".pdf":  "application/pdf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".zip":  "application/zip",
+	".txt":  "text/plain",
+	".csv":  "text/csv",
+}
+
+// mimeTypeByExtension returns the media type of a file name or URL from its extension.
+func mimeTypeByExtension(name string) string {
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	ext := strings.ToLower(path.Ext(name))
+	if ext == "" {
+		return ""
+	}
+	return mediaTypes[ext]
+}
@@ -0,0 +1,136 @@
+package wxr
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const attachmentsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Test Site</title>
+	<wp:base_site_url>https://example.com</wp:base_site_url>
+	<item>
+		<title><![CDATA[Sunset]]></title>
+		<excerpt:encoded><![CDATA[Sunset over the bay]]></excerpt:encoded>
+		<content:encoded><![CDATA[Taken from the pier.]]></content:encoded>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_date_gmt><![CDATA[2024-01-05 10:00:00]]></wp:post_date_gmt>
+		<wp:post_parent>1</wp:post_parent>
+		<wp:post_name><![CDATA[sunset]]></wp:post_name>
+		<wp:post_type><![CDATA[attachment]]></wp:post_type>
+		<wp:status><![CDATA[inherit]]></wp:status>
+		<wp:attachment_url><![CDATA[https://example.com/wp-content/uploads/2024/01/sunset.jpg]]></wp:attachment_url>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[_wp_attached_file]]></wp:meta_key>
+			<wp:meta_value><![CDATA[2024/01/sunset.jpg]]></wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[_wp_attachment_image_alt]]></wp:meta_key>
+			<wp:meta_value><![CDATA[Orange sky over the water]]></wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[_wp_attachment_metadata]]></wp:meta_key>
+			<wp:meta_value><![CDATA[a:5:{s:5:"width";i:1200;s:6:"height";i:800;s:4:"file";s:18:"2024/01/sunset.jpg";s:8:"filesize";i:204800;s:5:"sizes";a:2:{s:9:"thumbnail";a:4:{s:4:"file";s:18:"sunset-150x150.jpg";s:5:"width";i:150;s:6:"height";i:150;s:9:"mime-type";s:10:"image/jpeg";}s:6:"medium";a:4:{s:4:"file";s:18:"sunset-300x200.jpg";s:5:"width";i:300;s:6:"height";i:200;s:9:"mime-type";s:10:"image/jpeg";}}}]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title><![CDATA[Report]]></title>
+		<wp:post_id>11</wp:post_id>
+		<wp:post_type><![CDATA[attachment]]></wp:post_type>
+		<wp:status><![CDATA[inherit]]></wp:status>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[_wp_attached_file]]></wp:meta_key>
+			<wp:meta_value><![CDATA[2024/02/report.pdf]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title><![CDATA[Post]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+func TestParseDocument_Attachments(t *testing.T) {
+	doc, err := ParseDocument(context.Background(), strings.NewReader(attachmentsXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if len(doc.Posts) != 1 {
+		t.Errorf("expected attachments to stay out of posts, got %d posts", len(doc.Posts))
+	}
+	if len(doc.Attachments) != 2 {
+		t.Fatalf("expected 2 attachments, got %d", len(doc.Attachments))
+	}
+
+	image := doc.Attachments[0]
+	if image.ID != 10 || image.ParentID != 1 || image.Title != "Sunset" || image.Slug != "sunset" {
+		t.Errorf("unexpected identity fields: %+v", image)
+	}
+	if image.Caption != "Sunset over the bay" || image.Description != "Taken from the pier." || image.AltText != "Orange sky over the water" {
+		t.Errorf("unexpected editorial fields: caption %q, description %q, alt %q", image.Caption, image.Description, image.AltText)
+	}
+	if image.MIMEType != "image/jpeg" || image.RelativePath != "2024/01/sunset.jpg" || image.URL != "https://example.com/wp-content/uploads/2024/01/sunset.jpg" {
+		t.Errorf("unexpected file fields: %q %q %q", image.MIMEType, image.RelativePath, image.URL)
+	}
+	if image.Width != 1200 || image.Height != 800 || image.FileSize != 204800 {
+		t.Errorf("unexpected dimensions %dx%d, size %d", image.Width, image.Height, image.FileSize)
+	}
+	if image.Date != "2024-01-05T10:00:00Z" {
+		t.Errorf("unexpected date %q", image.Date)
+	}
+	wantThumb := AttachmentSize{
+		File:     "sunset-150x150.jpg",
+		URL:      "https://example.com/wp-content/uploads/2024/01/sunset-150x150.jpg",
+		Width:    150,
+		Height:   150,
+		MIMEType: "image/jpeg",
+	}
+	if len(image.Sizes) != 2 || image.Sizes["thumbnail"] != wantThumb || image.Sizes["medium"].Width != 300 {
+		t.Errorf("unexpected sizes: %+v", image.Sizes)
+	}
+
+	pdf := doc.Attachments[1]
+	if pdf.MIMEType != "application/pdf" || pdf.URL != "https://example.com/wp-content/uploads/2024/02/report.pdf" {
+		t.Errorf("unexpected document attachment: %+v", pdf)
+	}
+	if pdf.Width != 0 || len(pdf.Sizes) != 0 {
+		t.Errorf("expected no dimensions without metadata, got %+v", pdf)
+	}
+}
+
+func TestMIMETypeByExtension(t *testing.T) {
+	tests := map[string]string{
+		"2024/01/photo.JPG":                     "image/jpeg",
+		"https://example.com/clip.mp4?ver=2":    "video/mp4",
+		"https://example.com/song.mp3#t=10":     "audio/mpeg",
+		"archive.docx":                          "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"https://example.com/?attachment_id=12": "",
+		"notes.xyz-unknown":                     "",
+	}
+	for name, want := range tests {
+		if got := mimeTypeByExtension(name); got != want {
+			t.Errorf("mimeTypeByExtension(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseDocument_CorruptAttachmentMetadata(t *testing.T) {
+	for _, metadata := range []string{"a:-1:{}", "a:99999999999999:{}", `C:1:"X":9223372036854775807:{}`} {
+		xml := strings.Replace(attachmentsXML, `a:5:{s:5:"width";`, metadata+`a:5:{s:5:"width";`, 1)
+		doc, err := ParseDocument(context.Background(), strings.NewReader(xml))
+		if err != nil {
+			t.Fatalf("ParseDocument() error = %v", err)
+		}
+		if image := doc.Attachments[0]; image.Width != 0 || len(image.Sizes) != 0 || image.URL == "" {
+			t.Errorf("metadata %q: expected the attachment without dimensions, got %+v", metadata, image)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package wxr
+
+import "strings"
+
+// Author is a WordPress user declared at the channel level of an export (wp:author).
+type Author struct {
+	// ID is the WordPress user ID.
+	ID int
+
+	// Login is the user login, referenced by the dc:creator element of each item.
+	Login string
+
+	// Email is the user email address.
+	Email string
+
+	// DisplayName is the name WordPress shows for the user.
+	DisplayName string
+
+	// FirstName is the user's first name.
+	FirstName string
+
+	// LastName is the user's last name.
+	LastName string
+}
+
+// Name returns the best human-readable name of the author: the display name,
+// then the first and last name, then the login.
+func (a Author) Name() string {
+	if a.DisplayName != "" {
+		return a.DisplayName
+	}
+	if full := strings.TrimSpace(a.FirstName + " " + a.LastName); full != "" {
+		return full
+	}
+	return a.Login
+}
+
+// buildAuthors converts the channel-level author records in declaration order.
+func buildAuthors(ch channel) []Author {
+	authors := make([]Author, 0, len(ch.Authors))
+	for _, a := range ch.Authors {
+		authors = append(authors, Author{
+			ID:          a.ID,
+			Login:       strings.TrimSpace(a.Login),
+			Email:       strings.TrimSpace(a.Email),
+			DisplayName: strings.TrimSpace(a.DisplayName),
+			FirstName:   strings.TrimSpace(a.FirstName),
+			LastName:    strings.TrimSpace(a.LastName),
+		})
+	}
+	return authors
+}
+
+// authorsByLogin indexes authors by login.
+func authorsByLogin(authors []Author) map[string]Author {
+	byLogin := make(map[string]Author, len(authors))
+	for _, a := range authors {
+		if a.Login != "" {
+			byLogin[a.Login] = a
+		}
+	}
+	return byLogin
+}
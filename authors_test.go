@@ -0,0 +1,134 @@
+package wxr
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const authorsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Test Site</title>
+	<wp:author>
+		<wp:author_id>2</wp:author_id>
+		<wp:author_login><![CDATA[msilva]]></wp:author_login>
+		<wp:author_email><![CDATA[maria@example.com]]></wp:author_email>
+		<wp:author_display_name><![CDATA[Maria Silva]]></wp:author_display_name>
+		<wp:author_first_name><![CDATA[Maria]]></wp:author_first_name>
+		<wp:author_last_name><![CDATA[Silva]]></wp:author_last_name>
+	</wp:author>
+	<wp:author>
+		<wp:author_id>3</wp:author_id>
+		<wp:author_login><![CDATA[jsouza]]></wp:author_login>
+		<wp:author_email><![CDATA[joao@example.com]]></wp:author_email>
+		<wp:author_display_name><![CDATA[]]></wp:author_display_name>
+		<wp:author_first_name><![CDATA[João]]></wp:author_first_name>
+		<wp:author_last_name><![CDATA[Souza]]></wp:author_last_name>
+	</wp:author>
+	<item>
+		<title><![CDATA[Resolved]]></title>
+		<dc:creator><![CDATA[msilva]]></dc:creator>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[No Display Name]]></title>
+		<dc:creator><![CDATA[jsouza]]></dc:creator>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Unknown Login]]></title>
+		<dc:creator><![CDATA[guest]]></dc:creator>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>3</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Meta Override]]></title>
+		<dc:creator><![CDATA[msilva]]></dc:creator>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>4</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[author_name]]></wp:meta_key>
+			<wp:meta_value><![CDATA[Guest Columnist]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+</channel>
+</rss>`
+
+func TestParseDocument_Authors(t *testing.T) {
+	doc, err := ParseDocument(context.Background(), strings.NewReader(authorsXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+
+	if len(doc.Authors) != 2 {
+		t.Fatalf("expected 2 authors, got %d", len(doc.Authors))
+	}
+	want := Author{ID: 2, Login: "msilva", Email: "maria@example.com", DisplayName: "Maria Silva", FirstName: "Maria", LastName: "Silva"}
+	if doc.Authors[0] != want {
+		t.Errorf("Authors[0] = %+v, want %+v", doc.Authors[0], want)
+	}
+
+	tests := []struct {
+		id          int
+		author      string
+		authorLogin string
+	}{
+		{1, "Maria Silva", "msilva"},
+		{2, "João Souza", "jsouza"},
+		{3, "guest", "guest"},
+		{4, "Guest Columnist", "msilva"},
+	}
+	if len(doc.Posts) != len(tests) {
+		t.Fatalf("expected %d posts, got %d", len(tests), len(doc.Posts))
+	}
+	for i, tt := range tests {
+		post := doc.Posts[i]
+		if post.ID != tt.id || post.Author != tt.author || post.AuthorLogin != tt.authorLogin {
+			t.Errorf("post %d: Author = %q, AuthorLogin = %q, want %q, %q", post.ID, post.Author, post.AuthorLogin, tt.author, tt.authorLogin)
+		}
+	}
+}
+
+func TestStream_Authors(t *testing.T) {
+	stream := NewParser().NewStream(strings.NewReader(authorsXML))
+	post, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if post.Author != "Maria Silva" {
+		t.Errorf("expected resolved author in stream, got %q", post.Author)
+	}
+	if len(stream.Authors()) != 2 {
+		t.Errorf("expected stream to expose 2 authors, got %+v", stream.Authors())
+	}
+}
+
+func TestAuthor_Name(t *testing.T) {
+	tests := []struct {
+		author Author
+		want   string
+	}{
+		{Author{Login: "msilva", DisplayName: "Maria Silva", FirstName: "M"}, "Maria Silva"},
+		{Author{Login: "msilva", FirstName: "Maria"}, "Maria"},
+		{Author{Login: "msilva"}, "msilva"},
+	}
+	for _, tt := range tests {
+		if got := tt.author.Name(); got != tt.want {
+			t.Errorf("%+v.Name() = %q, want %q", tt.author, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package wxr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// autopBlocks matches the names of the elements AutoP treats as block-level.
+const autopBlocks = `(?:table|thead|tfoot|caption|col|colgroup|tbody|tr|td|th|div|dl|dd|dt|ul|ol|li|pre|form|map|area|blockquote|address|style|p|h[1-6]|hr|fieldset|legend|section|article|aside|hgroup|header|footer|nav|figure|figcaption|details|menu|summary)`
+
+var (
+	autopDoubleBR          = regexp.MustCompile(`<br\s*/?>\s*<br\s*/?>`)
+	autopBlockOpen         = regexp.MustCompile(`(<` + autopBlocks + `[\s/>])`)
+	autopBlockClose        = regexp.MustCompile(`(</` + autopBlocks + `>)`)
+	autopHR                = regexp.MustCompile(`(<hr\s*?/?>)`)
+	autopOptionOpen        = regexp.MustCompile(`\s*<option`)
+	autopOptionClose       = regexp.MustCompile(`</option>\s*`)
+	autopObjectOpen        = regexp.MustCompile(`(<object[^>]*>)\s*`)
+	autopObjectClose       = regexp.MustCompile(`\s*</object>`)
+	autopObjectParams      = regexp.MustCompile(`\s*(</?(?:param|embed)[^>]*>)\s*`)
+	autopMediaOpen         = regexp.MustCompile(`([<\[](?:audio|video)[^>\]]*[>\]])\s*`)
+	autopMediaClose        = regexp.MustCompile(`\s*([<\[]/(?:audio|video)[>\]])`)
+	autopMediaSources      = regexp.MustCompile(`\s*(<(?:source|track)[^>]*>)\s*`)
+	autopFigcaptionOpen    = regexp.MustCompile(`\s*(<figcaption[^>]*>)`)
+	autopFigcaptionClose   = regexp.MustCompile(`</figcaption>\s*`)
+	autopLineBreaks        = regexp.MustCompile(`\n\n+`)
+	autopParagraphSplit    = regexp.MustCompile(`\n\s*\n`)
+	autopEmptyParagraph    = regexp.MustCompile(`<p>\s*</p>`)
+	autopUnclosedContainer = regexp.MustCompile(`<p>([^<]+)</(div|address|form)>`)
+	autopWrappedBlock      = regexp.MustCompile(`<p>\s*(</?` + autopBlocks + `[^>]*>)\s*</p>`)
+	autopWrappedItem       = regexp.MustCompile(`<p>(<li.+?)</p>`)
+	autopWrappedQuote      = regexp.MustCompile(`(?i)<p><blockquote([^>]*)>`)
+	autopOpenBeforeBlock   = regexp.MustCompile(`<p>\s*(</?` + autopBlocks + `[^>]*>)`)
+	autopCloseAfterBlock   = regexp.MustCompile(`(</?` + autopBlocks + `[^>]*>)\s*</p>`)
+	autopPreserveNewlines  = regexp.MustCompile(`<(script|style|svg|math)`)
+	autopBRAfterBlock      = regexp.MustCompile(`(</?` + autopBlocks + `[^>]*>)\s*<br />`)
+	autopBRBeforeBlock     = regexp.MustCompile(`<br />(\s*</?(?:p|li|div|dl|dd|dt|th|pre|td|ul|ol)[^>]*>)`)
+)
+
+// AutoP converts double line breaks in text into paragraphs, like WordPress's
+// wpautop(). When br is true, remaining line breaks become <br /> tags.
+//
+// WordPress stores classic editor content as typed and runs wpautop() when it is
+// displayed, so ContentRendered of such posts has no <p> tags; see
+// Parser.WithAutoP. The contents of <pre> elements and the line breaks inside
+// tags, <script>, <style>, <svg> and <math> are left untouched.
+func AutoP(text string, br bool) string {
+	if strings.Trim(text, " \t\n\r\x00\x0B") == "" {
+		return ""
+	}
+
+	// Just to make things a little easier, pad the end.
+	text += "\n"
+
+	// Pre tags shouldn't be touched by autop. Replace them with placeholders and
+	// bring them back after autop.
+	var preTags [][2]string
+	if strings.Contains(text, "<pre") {
+		parts := strings.Split(text, "</pre>")
+		last := parts[len(parts)-1]
+		var b strings.Builder
+		for _, part := range parts[:len(parts)-1] {
+			start := strings.Index(part, "<pre")
+			if start < 0 {
+				// Malformed HTML
+				b.WriteString(part)
+				continue
+			}
+			name := "<pre wp-pre-tag-" + strconv.Itoa(len(preTags)) + "></pre>"
+			preTags = append(preTags, [2]string{name, part[start:] + "</pre>"})
+			b.WriteString(part[:start] + name)
+		}
+		b.WriteString(last)
+		text = b.String()
+	}
+
+	// Change multiple <br>'s into two line breaks, which will turn into paragraphs.
+	text = autopDoubleBR.ReplaceAllString(text, "\n\n")
+
+	// Add a double line break above block-level opening tags and below closing tags.
+	text = autopBlockOpen.ReplaceAllString(text, "\n\n$1")
+	text = autopBlockClose.ReplaceAllString(text, "$1\n\n")
+
+	// Add a double line break after hr tags, which are self closing.
+	text = autopHR.ReplaceAllString(text, "$1\n\n")
+
+	// Standardize newline characters to "\n".
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	// Find newlines in all elements and add placeholders.
+	text = replaceInHTMLTags(text, "\n", " <!-- wpnl --> ")
+
+	// Collapse line breaks before and after <option> elements so they don't get autop'd.
+	if strings.Contains(text, "<option") {
+		text = autopOptionOpen.ReplaceAllString(text, "<option")
+		text = autopOptionClose.ReplaceAllString(text, "</option>")
+	}
+
+	// Collapse line breaks inside <object> elements, before <param> and <embed>
+	// elements so they don't get autop'd.
+	if strings.Contains(text, "</object>") {
+		text = autopObjectOpen.ReplaceAllString(text, "$1")
+		text = autopObjectClose.ReplaceAllString(text, "</object>")
+		text = autopObjectParams.ReplaceAllString(text, "$1")
+	}
+
+	// Collapse line breaks inside <audio> and <video> elements, before and after
+	// <source> and <track> elements.
+	if strings.Contains(text, "<source") || strings.Contains(text, "<track") {
+		text = autopMediaOpen.ReplaceAllString(text, "$1")
+		text = autopMediaClose.ReplaceAllString(text, "$1")
+		text = autopMediaSources.ReplaceAllString(text, "$1")
+	}
+
+	// Collapse line breaks before and after <figcaption> elements.
+	if strings.Contains(text, "<figcaption") {
+		text = autopFigcaptionOpen.ReplaceAllString(text, "$1")
+		text = autopFigcaptionClose.ReplaceAllString(text, "</figcaption>")
+	}
+
+	// Remove more than two contiguous line breaks.
+	text = autopLineBreaks.ReplaceAllString(text, "\n\n")
+
+	// Split up the contents into paragraphs separated by double line breaks and
+	// wrap every bit with a <p>.
+	var b strings.Builder
+	for _, paragraph := range autopParagraphSplit.Split(text, -1) {
+		if paragraph != "" {
+			b.WriteString("<p>" + strings.Trim(paragraph, "\n") + "</p>\n")
+		}
+	}
+	text = b.String()
+
+	// Under certain strange conditions it could create a P of entirely whitespace.
+	text = autopEmptyParagraph.ReplaceAllString(text, "")
+
+	// Add a closing <p> inside <div>, <address>, or <form> tag if missing.
+	text = autopUnclosedContainer.ReplaceAllString(text, "<p>$1</p></$2>")
+
+	// If an opening or closing block element tag is wrapped in a <p>, unwrap it.
+	text = autopWrappedBlock.ReplaceAllString(text, "$1")
+
+	// In some cases <li> may get wrapped in <p>, fix them.
+	text = autopWrappedItem.ReplaceAllString(text, "$1")
+
+	// If a <blockquote> is wrapped with a <p>, move it inside the <blockquote>.
+	text = autopWrappedQuote.ReplaceAllString(text, "<blockquote$1><p>")
+	text = strings.ReplaceAll(text, "</blockquote></p>", "</p></blockquote>")
+
+	// If an opening or closing block element tag is preceded by an opening <p>
+	// tag or followed by a closing </p> tag, remove it.
+	text = autopOpenBeforeBlock.ReplaceAllString(text, "$1")
+	text = autopCloseAfterBlock.ReplaceAllString(text, "$1")
+
+	// Optionally insert line breaks.
+	if br {
+		// Replace newlines that shouldn't be touched with a placeholder.
+		text = preserveNewlines(text)
+
+		// Normalize <br>
+		text = strings.ReplaceAll(text, "<br>", "<br />")
+		text = strings.ReplaceAll(text, "<br/>", "<br />")
+
+		// Replace any new line characters that aren't preceded by a <br /> with a <br />.
+		text = insertLineBreaks(text)
+
+		// Replace newline placeholders with newlines.
+		text = strings.ReplaceAll(text, "<WPPreserveNewline />", "\n")
+	}
+
+	// If a <br /> tag is after an opening or closing block tag, or before a subset
+	// of them, remove it.
+	text = autopBRAfterBlock.ReplaceAllString(text, "$1")
+	text = autopBRBeforeBlock.ReplaceAllString(text, "$1")
+	if strings.HasSuffix(text, "\n</p>\n") {
+		text = text[:len(text)-len("\n</p>\n")] + "</p>\n"
+	} else if strings.HasSuffix(text, "\n</p>") {
+		text = text[:len(text)-len("\n</p>")] + "</p>"
+	}
+
+	// Replace placeholder <pre> tags with their original content.
+	for _, pre := range preTags {
+		text = strings.ReplaceAll(text, pre[0], pre[1])
+	}
+
+	// Restore newlines in all elements.
+	if strings.Contains(text, "<!-- wpnl -->") {
+		text = strings.ReplaceAll(text, " <!-- wpnl --> ", "\n")
+		text = strings.ReplaceAll(text, "<!-- wpnl -->", "\n")
+	}
+	return text
+}
+
+// replaceInHTMLTags replaces old with new inside tags and comments only, like
+// WordPress's wp_replace_in_html_tags(). As in wp_html_split(), a tag runs from
+// "<" to the next ">" or the end of the text.
+func replaceInHTMLTags(text, old, new string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		lt := strings.IndexByte(text[i:], '<')
+		if lt < 0 {
+			b.WriteString(text[i:])
+			break
+		}
+		b.WriteString(text[i : i+lt])
+		i += lt
+
+		var end int
+		switch rest := text[i+1:]; {
+		case strings.HasPrefix(rest, "!--"):
+			end = closingIndex(text, i+2, "-->")
+		case strings.HasPrefix(rest, "![CDATA["):
+			end = closingIndex(text, i+len("<![CDATA["), "]]>")
+		default:
+			end = closingIndex(text, i+1, ">")
+		}
+		b.WriteString(strings.ReplaceAll(text[i:end], old, new))
+		i = end
+	}
+	return b.String()
+}
+
+// closingIndex returns the index after the first delim at or after i, or the
+// length of text if there is none.
+func closingIndex(text string, i int, delim string) int {
+	if j := strings.Index(text[i:], delim); j >= 0 {
+		return i + j + len(delim)
+	}
+	return len(text)
+}
+
+// preserveNewlines replaces the newlines of <script>, <style>, <svg> and <math>
+// elements with a placeholder, so that no <br /> is inserted in them.
+func preserveNewlines(text string) string {
+	var b strings.Builder
+	i := 0
+	for _, m := range autopPreserveNewlines.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] < i {
+			continue // inside the previous element
+		}
+		end := strings.Index(text[m[1]:], "</"+text[m[2]:m[3]]+">")
+		if end < 0 {
+			continue
+		}
+		end += m[1] + len("</") + m[3] - m[2] + len(">")
+		b.WriteString(text[i:m[0]])
+		b.WriteString(strings.ReplaceAll(text[m[0]:end], "\n", "<WPPreserveNewline />"))
+		i = end
+	}
+	b.WriteString(text[i:])
+	return b.String()
+}
+
+// insertLineBreaks replaces each newline, with the whitespace before it, by
+// "<br />\n" unless the whitespace follows a <br />.
+func insertLineBreaks(text string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(text); i++ {
+		if i >= len("<br />") && text[i-len("<br />"):i] == "<br />" {
+			continue
+		}
+		j, newline := i, -1
+		for j < len(text) && isPCRESpace(text[j]) {
+			if text[j] == '\n' {
+				newline = j
+			}
+			j++
+		}
+		if newline < 0 {
+			continue
+		}
+		b.WriteString(text[last:i])
+		b.WriteString("<br />\n")
+		last = newline + 1
+		i = newline
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isPCRESpace reports whether c matches \s in a PCRE pattern.
+func isPCRESpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+// hasBlocks reports whether content was written with the block editor, like
+// WordPress's has_blocks().
+func hasBlocks(content string) bool {
+	return strings.Contains(content, "<!-- wp:")
+}
@@ -0,0 +1,146 @@
+package wxr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoP(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "paragraphs and line breaks",
+			text: "Hello\nWorld\n\nNew para",
+			want: "<p>Hello<br />\nWorld</p>\n<p>New para</p>\n",
+		},
+		{
+			name: "windows line endings",
+			text: "One\r\n\r\nTwo",
+			want: "<p>One</p>\n<p>Two</p>\n",
+		},
+		{
+			name: "empty",
+			text: " \n\t",
+			want: "",
+		},
+		{
+			name: "pre is untouched",
+			text: "First\n<pre>a\n\nb</pre>\nAfter",
+			want: "<p>First</p>\n<pre>a\n\nb</pre>\n<p>After</p>\n",
+		},
+		{
+			name: "block elements are not wrapped",
+			text: "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\nText",
+			want: "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<p>Text</p>\n",
+		},
+		{
+			name: "blockquote",
+			text: "<blockquote>Quote\n\nMore</blockquote>",
+			want: "<blockquote><p>Quote</p>\n<p>More</p></blockquote>\n",
+		},
+		{
+			name: "double br becomes a paragraph break",
+			text: "Line<br>\n<br/>Next",
+			want: "<p>Line</p>\n<p>Next</p>\n",
+		},
+		{
+			name: "newlines inside tags are kept",
+			text: "<a href=\"x\"\ntitle=\"y\">link</a>\nend",
+			want: "<p><a href=\"x\"\ntitle=\"y\">link</a><br />\nend</p>\n",
+		},
+		{
+			name: "script newlines are kept",
+			text: "<script>a\nb</script>\ntext",
+			want: "<p><script>a\nb</script><br />\ntext</p>\n",
+		},
+		{
+			name: "br already present",
+			text: "a<br />\nb",
+			want: "<p>a<br />\nb</p>\n",
+		},
+		{
+			name: "figure caption",
+			text: "<figure><img src=\"a.jpg\">\n<figcaption>Cap</figcaption>\n</figure>",
+			want: "<figure><img src=\"a.jpg\"><figcaption>Cap</figcaption></figure>\n",
+		},
+		{
+			name: "unclosed paragraph in div",
+			text: "<div>one\n\ntwo</div>",
+			want: "<div>one</p>\n<p>two</p></div>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AutoP(tt.text, true); got != tt.want {
+				t.Errorf("AutoP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoP_NoBR(t *testing.T) {
+	got := AutoP("Hello\nWorld\n\nNew para", false)
+	if want := "<p>Hello\nWorld</p>\n<p>New para</p>\n"; got != want {
+		t.Errorf("AutoP() = %q, want %q", got, want)
+	}
+}
+
+func TestParser_WithAutoP(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title>Classic</title>
+		<content:encoded><![CDATA[First line
+second line
+
+Second paragraph]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+	<item>
+		<title>Blocks</title>
+		<content:encoded><![CDATA[<!-- wp:paragraph -->
+<p>Block</p>
+<!-- /wp:paragraph -->]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+</channel>
+</rss>`
+
+	posts, err := NewParser().WithAutoP(true).Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(posts))
+	}
+	if want := "<p>First line<br />\nsecond line</p>\n<p>Second paragraph</p>\n"; posts[0].ContentRendered != want {
+		t.Errorf("classic content = %q, want %q", posts[0].ContentRendered, want)
+	}
+	if want := "<!-- wp:paragraph -->\n<p>Block</p>\n<!-- /wp:paragraph -->"; posts[1].ContentRendered != want {
+		t.Errorf("block content = %q, want %q", posts[1].ContentRendered, want)
+	}
+
+	posts, err = NewParser().Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if strings.Contains(posts[0].ContentRendered, "<p>") {
+		t.Errorf("expected raw content without WithAutoP, got %q", posts[0].ContentRendered)
+	}
+
+	md := NewMarkdownConverter().WithAutoP(true).Convert(posts[0].ContentRendered)
+	if want := "First line  \nsecond line\n\nSecond paragraph"; md != want {
+		t.Errorf("Markdown = %q, want %q", md, want)
+	}
+}
@@ -0,0 +1,487 @@
+package wxr
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Block is a Gutenberg block parsed from post content.
+// The fields mirror the arrays returned by WordPress's parse_blocks().
+type Block struct {
+	// Name is the block name with its namespace, such as "core/paragraph".
+	// It is empty for freeform HTML found outside of any block delimiter.
+	Name string
+
+	// Attrs are the JSON attributes of the block comment delimiter. They are
+	// empty when the delimiter has no attributes and nil when they are not valid JSON.
+	Attrs map[string]any
+
+	// InnerBlocks are the blocks nested in this block.
+	InnerBlocks []*Block
+
+	// InnerHTML is the HTML of the block without its inner blocks.
+	InnerHTML string
+
+	// InnerContent is InnerHTML split around the inner blocks: a nil entry marks
+	// the position of the next block of InnerBlocks.
+	InnerContent []*string
+}
+
+// ParseBlocks parses post content into blocks using the grammar of WordPress's
+// WP_Block_Parser. HTML outside of block delimiters becomes freeform blocks with
+// an empty Name, so serializing the result reproduces the input.
+func ParseBlocks(content string) []*Block {
+	p := &blockParser{document: content}
+	for p.proceed() {
+	}
+	return p.output
+}
+
+// Blocks parses the post content into Gutenberg blocks. See ParseBlocks.
+func (p Post) Blocks() []*Block {
+	return ParseBlocks(p.ContentRendered)
+}
+
+// IsFreeform reports whether the block is HTML outside of any block delimiter.
+func (b *Block) IsFreeform() bool {
+	return b.Name == ""
+}
+
+// Serialize renders the block back into post content with its comment delimiters,
+// as WordPress's serialize_block() does. Attribute keys are written in sorted order.
+func (b *Block) Serialize() string {
+	var content strings.Builder
+	index := 0
+	for _, chunk := range b.InnerContent {
+		if chunk != nil {
+			content.WriteString(*chunk)
+		} else if index < len(b.InnerBlocks) {
+			content.WriteString(b.InnerBlocks[index].Serialize())
+			index++
+		}
+	}
+	if b.Name == "" {
+		return content.String()
+	}
+
+	name := strings.TrimPrefix(b.Name, "core/")
+	attrs := ""
+	if len(b.Attrs) > 0 {
+		attrs = serializeBlockAttributes(b.Attrs) + " "
+	}
+	if content.Len() == 0 || content.String() == "0" {
+		return "<!-- wp:" + name + " " + attrs + "/-->"
+	}
+	return "<!-- wp:" + name + " " + attrs + "-->" + content.String() + "<!-- /wp:" + name + " -->"
+}
+
+// SerializeBlocks renders blocks back into post content.
+func SerializeBlocks(blocks []*Block) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		b.WriteString(block.Serialize())
+	}
+	return b.String()
+}
+
+// WalkBlocks calls fn for each block in depth-first order.
+// If fn returns false, the inner blocks of that block are not visited.
+func WalkBlocks(blocks []*Block, fn func(*Block) bool) {
+	for _, block := range blocks {
+		if fn(block) {
+			WalkBlocks(block.InnerBlocks, fn)
+		}
+	}
+}
+
+// FilterBlocks returns the blocks for which keep returns true, removing the
+// others at any depth. The InnerContent of parent blocks is updated so that
+// serializing the result leaves the dropped blocks out. Blocks are modified in place.
+func FilterBlocks(blocks []*Block, keep func(*Block) bool) []*Block {
+	kept := make([]*Block, 0, len(blocks))
+	for _, block := range blocks {
+		if !keep(block) {
+			continue
+		}
+		if len(block.InnerBlocks) > 0 {
+			block.filterInner(keep)
+		}
+		kept = append(kept, block)
+	}
+	return kept
+}
+
+// filterInner removes the inner blocks rejected by keep together with their
+// InnerContent placeholders.
+func (b *Block) filterInner(keep func(*Block) bool) {
+	inner := make([]*Block, 0, len(b.InnerBlocks))
+	content := make([]*string, 0, len(b.InnerContent))
+	index := 0
+	for _, chunk := range b.InnerContent {
+		if chunk != nil {
+			content = append(content, chunk)
+			continue
+		}
+		if index >= len(b.InnerBlocks) {
+			continue
+		}
+		child := b.InnerBlocks[index]
+		index++
+		if !keep(child) {
+			continue
+		}
+		if len(child.InnerBlocks) > 0 {
+			child.filterInner(keep)
+		}
+		inner = append(inner, child)
+		content = append(content, nil)
+	}
+	b.InnerBlocks = inner
+	b.InnerContent = content
+}
+
+// serializeBlockAttributes encodes block attributes as WordPress does, escaping
+// the sequences that would break out of an HTML comment.
+func serializeBlockAttributes(attrs map[string]any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(attrs); err != nil {
+		return "{}"
+	}
+	encoded := strings.TrimSuffix(buf.String(), "\n")
+	return strings.NewReplacer(
+		"--", `\u002d\u002d`,
+		"<", `\u003c`,
+		">", `\u003e`,
+		"&", `\u0026`,
+		`\"`, `\u0022`,
+	).Replace(encoded)
+}
+
+// blockToken kinds returned by blockParser.nextToken.
+const (
+	tokenNone = iota
+	tokenVoid
+	tokenOpener
+	tokenCloser
+)
+
+// blockToken is a block comment delimiter found in the document.
+type blockToken struct {
+	kind   int
+	name   string
+	attrs  map[string]any
+	start  int
+	length int
+}
+
+// blockFrame is an open block waiting for its closer.
+type blockFrame struct {
+	block            *Block
+	tokenStart       int
+	tokenLength      int
+	prevOffset       int
+	leadingHTMLStart int // -1 when there was no HTML before the opener
+}
+
+// blockParser is a port of WordPress's WP_Block_Parser.
+type blockParser struct {
+	document string
+	offset   int
+	output   []*Block
+	stack    []*blockFrame
+}
+
+// proceed processes the next token and reports whether parsing should continue.
+func (p *blockParser) proceed() bool {
+	token := p.nextToken()
+	depth := len(p.stack)
+
+	leadingHTMLStart := -1
+	if token.start > p.offset {
+		leadingHTMLStart = p.offset
+	}
+
+	switch token.kind {
+	case tokenNone:
+		// No more delimiters: everything left is freeform HTML or closes open blocks
+		if depth == 0 {
+			p.addFreeform()
+			return false
+		}
+		for len(p.stack) > 0 {
+			p.addBlockFromStack(-1)
+		}
+		return false
+
+	case tokenVoid:
+		block := &Block{Name: token.name, Attrs: token.attrs}
+		if depth == 0 {
+			if leadingHTMLStart >= 0 {
+				p.output = append(p.output, freeformBlock(p.document[leadingHTMLStart:token.start]))
+			}
+			p.output = append(p.output, block)
+			p.offset = token.start + token.length
+			return true
+		}
+		p.addInnerBlock(block, token.start, token.length, -1)
+		p.offset = token.start + token.length
+		return true
+
+	case tokenOpener:
+		p.stack = append(p.stack, &blockFrame{
+			block:            &Block{Name: token.name, Attrs: token.attrs},
+			tokenStart:       token.start,
+			tokenLength:      token.length,
+			prevOffset:       token.start + token.length,
+			leadingHTMLStart: leadingHTMLStart,
+		})
+		p.offset = token.start + token.length
+		return true
+
+	case tokenCloser:
+		// A closer without an opener: treat the rest of the document as freeform HTML
+		if depth == 0 {
+			p.addFreeform()
+			return false
+		}
+		if depth == 1 {
+			p.addBlockFromStack(token.start)
+			p.offset = token.start + token.length
+			return true
+		}
+
+		// Nested: close the current block and add it to its parent
+		top := p.stack[depth-1]
+		p.stack = p.stack[:depth-1]
+		html := p.document[top.prevOffset:token.start]
+		top.block.InnerHTML += html
+		top.block.InnerContent = append(top.block.InnerContent, &html)
+		top.prevOffset = token.start + token.length
+		p.addInnerBlock(top.block, top.tokenStart, top.tokenLength, token.start+token.length)
+		p.offset = token.start + token.length
+		return true
+	}
+
+	p.addFreeform()
+	return false
+}
+
+// nextToken finds the next block comment delimiter at or after the current offset.
+func (p *blockParser) nextToken() blockToken {
+	for i := p.offset; ; {
+		j := strings.Index(p.document[i:], "<!--")
+		if j < 0 {
+			return blockToken{kind: tokenNone}
+		}
+		i += j
+		if token, ok := matchBlockDelimiter(p.document, i); ok {
+			return token
+		}
+		i++
+	}
+}
+
+// addFreeform adds the rest of the document as a freeform block.
+func (p *blockParser) addFreeform() {
+	if p.offset >= len(p.document) {
+		return
+	}
+	p.output = append(p.output, freeformBlock(p.document[p.offset:]))
+}
+
+// addInnerBlock adds block to the innermost open block, together with the HTML
+// that precedes it. lastOffset is where the parent continues, or -1 for the end
+// of the block's token.
+func (p *blockParser) addInnerBlock(block *Block, tokenStart, tokenLength, lastOffset int) {
+	parent := p.stack[len(p.stack)-1]
+	parent.block.InnerBlocks = append(parent.block.InnerBlocks, block)
+	html := p.document[parent.prevOffset:tokenStart]
+	if !phpEmpty(html) {
+		parent.block.InnerHTML += html
+		parent.block.InnerContent = append(parent.block.InnerContent, &html)
+	}
+	parent.block.InnerContent = append(parent.block.InnerContent, nil)
+	if lastOffset >= 0 {
+		parent.prevOffset = lastOffset
+	} else {
+		parent.prevOffset = tokenStart + tokenLength
+	}
+}
+
+// addBlockFromStack closes the innermost open block at endOffset, or at the end of
+// the document when endOffset is -1, and adds it to the output.
+func (p *blockParser) addBlockFromStack(endOffset int) {
+	top := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	var html string
+	if endOffset >= 0 {
+		html = p.document[top.prevOffset:endOffset]
+	} else {
+		html = p.document[top.prevOffset:]
+	}
+	if !phpEmpty(html) {
+		top.block.InnerHTML += html
+		top.block.InnerContent = append(top.block.InnerContent, &html)
+	}
+	if top.leadingHTMLStart >= 0 {
+		p.output = append(p.output, freeformBlock(p.document[top.leadingHTMLStart:top.tokenStart]))
+	}
+	p.output = append(p.output, top.block)
+}
+
+// freeformBlock wraps HTML found outside of block delimiters.
+func freeformBlock(html string) *Block {
+	return &Block{
+		Attrs:        map[string]any{},
+		InnerHTML:    html,
+		InnerContent: []*string{&html},
+	}
+}
+
+// phpEmpty mirrors PHP's empty() for strings, which also treats "0" as empty.
+func phpEmpty(s string) bool {
+	return s == "" || s == "0"
+}
+
+// matchBlockDelimiter matches a block comment delimiter starting at i, following
+// the expression used by WP_Block_Parser:
+//
+//	<!--\s+(/)?wp:([a-z][a-z0-9_-]*/)?([a-z][a-z0-9_-]*)\s+({...}\s+)?(/)?-->
+//
+// The attributes run from "{" to the first "}" that is followed by whitespace and
+// the end of the comment.
+func matchBlockDelimiter(doc string, i int) (blockToken, bool) {
+	start := i
+	i += len("<!--")
+	if n := skipSpace(doc, i); n == i {
+		return blockToken{}, false
+	} else {
+		i = n
+	}
+
+	closer := false
+	if i < len(doc) && doc[i] == '/' {
+		closer = true
+		i++
+	}
+	if !strings.HasPrefix(doc[i:], "wp:") {
+		return blockToken{}, false
+	}
+	i += len("wp:")
+
+	namespace := "core/"
+	first := blockIdent(doc, i)
+	if first == 0 {
+		return blockToken{}, false
+	}
+	name := doc[i : i+first]
+	i += first
+	if i < len(doc) && doc[i] == '/' {
+		second := blockIdent(doc, i+1)
+		if second == 0 {
+			return blockToken{}, false
+		}
+		namespace = name + "/"
+		name = doc[i+1 : i+1+second]
+		i += 1 + second
+	}
+
+	n := skipSpace(doc, i)
+	if n == i {
+		return blockToken{}, false
+	}
+	i = n
+
+	var attrs map[string]any
+	hasAttrs := false
+	if i < len(doc) && doc[i] == '{' {
+		end := attrsEnd(doc, i+1)
+		if end < 0 {
+			return blockToken{}, false
+		}
+		hasAttrs = true
+		if err := json.Unmarshal([]byte(doc[i:end+1]), &attrs); err != nil {
+			attrs = nil
+		}
+		i = skipSpace(doc, end+1)
+	}
+
+	void := false
+	if i < len(doc) && doc[i] == '/' {
+		void = true
+		i++
+	}
+	if !strings.HasPrefix(doc[i:], "-->") {
+		return blockToken{}, false
+	}
+	i += len("-->")
+
+	token := blockToken{name: namespace + name, start: start, length: i - start}
+	switch {
+	case void:
+		token.kind = tokenVoid
+	case closer:
+		token.kind = tokenCloser
+		return token, true
+	default:
+		token.kind = tokenOpener
+	}
+	if hasAttrs {
+		token.attrs = attrs
+	} else {
+		token.attrs = map[string]any{}
+	}
+	return token, true
+}
+
+// attrsEnd returns the index of the "}" closing block attributes that start before
+// i: the first "}" followed by whitespace, an optional "/" and "-->". It returns -1
+// if there is none.
+func attrsEnd(doc string, i int) int {
+	for {
+		j := strings.IndexByte(doc[i:], '}')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		k := skipSpace(doc, i+1)
+		if k > i+1 {
+			if k < len(doc) && doc[k] == '/' {
+				k++
+			}
+			if strings.HasPrefix(doc[k:], "-->") {
+				return i
+			}
+		}
+		i++
+	}
+}
+
+// blockIdent returns the length of a block name segment, [a-z][a-z0-9_-]*, at i.
+func blockIdent(doc string, i int) int {
+	if i >= len(doc) || doc[i] < 'a' || doc[i] > 'z' {
+		return 0
+	}
+	n := 1
+	for i+n < len(doc) {
+		c := doc[i+n]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' {
+			n++
+			continue
+		}
+		break
+	}
+	return n
+}
+
+// skipSpace returns the index of the first non-whitespace byte at or after i.
+func skipSpace(doc string, i int) int {
+	for i < len(doc) && strings.IndexByte(" \t\n\v\f\r", doc[i]) >= 0 {
+		i++
+	}
+	return i
+}
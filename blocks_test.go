@@ -0,0 +1,168 @@
+package wxr
+
+import (
+	"reflect"
+	"testing"
+)
+
+const blocksContent = `<!-- wp:paragraph -->
+<p>Hello</p>
+<!-- /wp:paragraph -->
+
+<!-- wp:columns {"columns":2} -->
+<div class="wp-block-columns"><!-- wp:column -->
+<div class="wp-block-column"><!-- wp:image {"id":5,"sizeSlug":"large"} /--></div>
+<!-- /wp:column -->
+
+<!-- wp:column -->
+<div class="wp-block-column"><!-- wp:embed {"providerNameSlug":"youtube","url":"https://youtu.be/x"} -->
+<figure>https://youtu.be/x</figure>
+<!-- /wp:embed --></div>
+<!-- /wp:column --></div>
+<!-- /wp:columns -->
+
+<!-- wp:my-plugin/notice {"type":"warning"} -->
+<div>Careful</div>
+<!-- /wp:my-plugin/notice -->`
+
+func strptr(s string) *string { return &s }
+
+func TestParseBlocks(t *testing.T) {
+	blocks := ParseBlocks(blocksContent)
+
+	var names []string
+	for _, b := range blocks {
+		names = append(names, b.Name)
+	}
+	want := []string{"core/paragraph", "", "core/columns", "", "my-plugin/notice"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("top-level blocks = %q, want %q", names, want)
+	}
+
+	paragraph := blocks[0]
+	if paragraph.InnerHTML != "\n<p>Hello</p>\n" || len(paragraph.Attrs) != 0 || paragraph.Attrs == nil {
+		t.Errorf("unexpected paragraph: %+v", paragraph)
+	}
+	if !blocks[1].IsFreeform() || blocks[1].InnerHTML != "\n\n" {
+		t.Errorf("expected freeform whitespace between blocks, got %+v", blocks[1])
+	}
+
+	columns := blocks[2]
+	if columns.Attrs["columns"] != float64(2) || len(columns.InnerBlocks) != 2 {
+		t.Fatalf("unexpected columns block: %+v", columns)
+	}
+	wantContent := []*string{strptr("\n<div class=\"wp-block-columns\">"), nil, strptr("\n\n"), nil, strptr("</div>\n")}
+	if !reflect.DeepEqual(columns.InnerContent, wantContent) {
+		t.Errorf("columns InnerContent = %q", derefAll(columns.InnerContent))
+	}
+	if columns.InnerHTML != "\n<div class=\"wp-block-columns\">\n\n</div>\n" {
+		t.Errorf("columns InnerHTML = %q", columns.InnerHTML)
+	}
+
+	image := columns.InnerBlocks[0].InnerBlocks[0]
+	if image.Name != "core/image" || image.Attrs["sizeSlug"] != "large" || image.InnerHTML != "" || len(image.InnerContent) != 0 {
+		t.Errorf("unexpected void image block: %+v", image)
+	}
+	embed := columns.InnerBlocks[1].InnerBlocks[0]
+	if embed.Name != "core/embed" || embed.Attrs["providerNameSlug"] != "youtube" || embed.InnerHTML != "\n<figure>https://youtu.be/x</figure>\n" {
+		t.Errorf("unexpected embed block: %+v", embed)
+	}
+
+	if notice := blocks[4]; notice.Attrs["type"] != "warning" {
+		t.Errorf("unexpected namespaced block: %+v", notice)
+	}
+}
+
+func TestParseBlocks_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		names   []string
+	}{
+		{"classic content", "<p>Just HTML</p>", []string{""}},
+		{"empty", "", nil},
+		{"leading html", "<p>Intro</p><!-- wp:separator /-->", []string{"", "core/separator"}},
+		{"unclosed block", "<!-- wp:quote --><p>Open", []string{"core/quote"}},
+		{"closer without opener", "<p>a</p><!-- /wp:quote --><p>b</p>", []string{""}},
+		{"not a delimiter", "<!--wp:paragraph--><p>x</p><!-- wp:Paragraph -->", []string{""}},
+		{"regular comment", "<!-- more --><!-- wp:more /-->", []string{"", "core/more"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := ParseBlocks(tt.content)
+			var names []string
+			for _, b := range blocks {
+				names = append(names, b.Name)
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("ParseBlocks(%q) names = %q, want %q", tt.content, names, tt.names)
+			}
+			if got := SerializeBlocks(blocks); got != tt.content && tt.name != "unclosed block" {
+				t.Errorf("SerializeBlocks() = %q, want %q", got, tt.content)
+			}
+		})
+	}
+
+	if blocks := ParseBlocks(`<!-- wp:image {"id":5,} /-->`); blocks[0].Attrs != nil {
+		t.Errorf("expected nil attrs for invalid JSON, got %+v", blocks[0].Attrs)
+	}
+	if blocks := ParseBlocks(`<!-- wp:code {"content":"a } b"} -->x<!-- /wp:code -->`); blocks[0].Attrs["content"] != "a } b" {
+		t.Errorf("expected braces inside attributes to be kept, got %+v", blocks[0].Attrs)
+	}
+}
+
+func TestSerializeBlocks_RoundTrip(t *testing.T) {
+	if got := SerializeBlocks(ParseBlocks(blocksContent)); got != blocksContent {
+		t.Errorf("round trip mismatch:\n%s", got)
+	}
+
+	block := &Block{
+		Name:  "core/html",
+		Attrs: map[string]any{"content": `<b>"a" -- & b</b>`},
+	}
+	want := `<!-- wp:html {"content":"\u003cb\u003e\u0022a\u0022 \u002d\u002d \u0026 b\u003c/b\u003e"} /-->`
+	if got := block.Serialize(); got != want {
+		t.Errorf("Serialize() = %s, want %s", got, want)
+	}
+	if parsed := ParseBlocks(want); parsed[0].Attrs["content"] != `<b>"a" -- & b</b>` {
+		t.Errorf("escaped attributes did not decode back: %+v", parsed[0].Attrs)
+	}
+}
+
+func TestFilterBlocks(t *testing.T) {
+	blocks := FilterBlocks(ParseBlocks(blocksContent), func(b *Block) bool {
+		return b.Name != "core/embed" && b.Name != "my-plugin/notice"
+	})
+	if len(blocks) != 4 {
+		t.Fatalf("expected 4 top-level blocks, got %d", len(blocks))
+	}
+
+	var names []string
+	WalkBlocks(blocks, func(b *Block) bool {
+		if !b.IsFreeform() {
+			names = append(names, b.Name)
+		}
+		return true
+	})
+	want := []string{"core/paragraph", "core/columns", "core/column", "core/image", "core/column"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("remaining blocks = %q, want %q", names, want)
+	}
+
+	column := blocks[2].InnerBlocks[1]
+	if got := column.Serialize(); got != "<!-- wp:column -->\n<div class=\"wp-block-column\"></div>\n<!-- /wp:column -->" {
+		t.Errorf("filtered column = %q", got)
+	}
+}
+
+func derefAll(chunks []*string) []string {
+	var out []string
+	for _, c := range chunks {
+		if c == nil {
+			out = append(out, "<nil>")
+		} else {
+			out = append(out, *c)
+		}
+	}
+	return out
+}
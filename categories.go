@@ -2,8 +2,32 @@ package wxr
 
 import "strings"
 
+// itemTerms returns every term assigned to an item.
+// A <category> element without a domain attribute is a category.
+func itemTerms(item *item) []Term {
+	var terms []Term
+	for _, cat := range item.Categories {
+		name := strings.TrimSpace(cat.Value)
+		if name == "" {
+			continue
+		}
+		taxonomy := strings.ToLower(strings.TrimSpace(cat.Domain))
+		if taxonomy == "" {
+			taxonomy = "category"
+		}
+		terms = append(terms, Term{
+			Taxonomy: taxonomy,
+			Name:     name,
+			Slug:     strings.TrimSpace(cat.NiceName),
+		})
+	}
+	return terms
+}
+
 // CategoryExtractor handles category and tag extraction from WXR items.
-type CategoryExtractor struct{}
+type CategoryExtractor struct {
+	taxonomies map[string]*Taxonomy
+}
 
 // ExtractCategories extracts category names from an item.
 // Categories are identified by domain="category" or no domain attribute.
@@ -35,3 +59,34 @@ func (e *CategoryExtractor) ExtractTags(item *item) []string {
 	}
 	return tags
 }
+
+// ExtractTerms extracts every term assigned to an item keyed by taxonomy, including
+// custom taxonomies such as product_cat, series, post_format or language.
+// Each term carries the display value and nicename from the item and is completed
+// with its ID, parent and description from the channel-level term definitions when
+// the export declares them.
+func (e *CategoryExtractor) ExtractTerms(item *item) map[string][]Term {
+	terms := make(map[string][]Term)
+	for _, term := range itemTerms(item) {
+		terms[term.Taxonomy] = append(terms[term.Taxonomy], e.resolve(term))
+	}
+	return terms
+}
+
+// resolve completes a term assigned to an item with its channel-level definition,
+// looked up by slug or, when the item gives no slug, by name.
+func (e *CategoryExtractor) resolve(term Term) Term {
+	taxonomy, ok := e.taxonomies[term.Taxonomy]
+	if !ok {
+		return term
+	}
+	def, ok := taxonomy.Term(term.Slug)
+	if !ok && term.Slug == "" {
+		def, ok = taxonomy.TermByName(term.Name)
+	}
+	if !ok {
+		return term
+	}
+	def.Name = term.Name
+	return def
+}
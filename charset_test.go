@@ -0,0 +1,146 @@
+package wxr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewCharsetReader(t *testing.T) {
+	tests := []struct {
+		charset string
+		input   string
+		want    string
+	}{
+		{"windows-1252", "Caf\xe9 \x93quoted\x94 \x96 \x80", "Café “quoted” – €"},
+		{"ISO-8859-1", "na\xefve \x85", "naïve …"},
+		{"US-ASCII", "plain", "plain"},
+		{"iso-8859-15", "\xa4 \xbd", "€ œ"},
+		{"windows-1250", "\x8a\xe8", "Šč"},
+		{"ISO-8859-2", "\xa3\xf3d\xbc", "Łódź"},
+		{"windows-1251", "\xcf\xf0\xe8\xe2\xe5\xf2", "Привет"},
+		{"KOI8-R", "\xf0\xd2\xc9\xd7\xc5\xd4", "Привет"},
+		{"UTF-8", "Café", "Café"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.charset, func(t *testing.T) {
+			r, err := NewCharsetReader(tt.charset, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewCharsetReader() error = %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewCharsetReader("shift_jis", strings.NewReader("")); !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("expected ErrUnsupportedCharset, got %v", err)
+	}
+}
+
+// legacyDocument returns a one-post export declaring encoding, with title and
+// content given in that encoding.
+func legacyDocument(encoding, title, content string) string {
+	return `<?xml version="1.0" encoding="` + encoding + `"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>` + title + `</title>
+	<item>
+		<title>` + title + `</title>
+		<content:encoded><![CDATA[` + content + `]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+</channel>
+</rss>`
+}
+
+func TestParse_LegacyCharset(t *testing.T) {
+	input := legacyDocument("ISO-8859-1", "Caf\xe9 cr\xe8me", "<p>\x93Quoted\x94 na\xefve text</p>")
+
+	for _, recovery := range []bool{false, true} {
+		doc, err := NewParser().WithRecovery(recovery).ParseDocument(context.Background(), strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("ParseDocument(recovery %v) error = %v", recovery, err)
+		}
+		if doc.Site.Title != "Café crème" {
+			t.Errorf("Site.Title = %q, want %q", doc.Site.Title, "Café crème")
+		}
+		if len(doc.Posts) != 1 {
+			t.Fatalf("expected 1 post, got %d", len(doc.Posts))
+		}
+		post := doc.Posts[0]
+		if post.TitleRendered != "Café crème" {
+			t.Errorf("TitleRendered = %q, want %q", post.TitleRendered, "Café crème")
+		}
+		if want := "<p>“Quoted” naïve text</p>"; post.ContentRendered != want {
+			t.Errorf("ContentRendered = %q, want %q", post.ContentRendered, want)
+		}
+		if len(doc.Diagnostics) != 0 {
+			t.Errorf("unexpected Diagnostics %v", doc.Diagnostics)
+		}
+	}
+}
+
+func TestParser_WithCharsetReader(t *testing.T) {
+	input := legacyDocument("x-rot13", "Uryyb", "")
+
+	if _, err := Parse(strings.NewReader(input)); !errors.Is(err, ErrUnsupportedCharset) {
+		t.Fatalf("expected ErrUnsupportedCharset, got %v", err)
+	}
+
+	var charsets []string
+	rot13 := func(charset string, input io.Reader) (io.Reader, error) {
+		charsets = append(charsets, charset)
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		// A stand-in decoder that only knows the title
+		return strings.NewReader(strings.Replace(string(data), "Uryyb", "Hello", -1)), nil
+	}
+	posts, err := NewParser().WithCharsetReader(rot13).Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(posts) != 1 || posts[0].TitleRendered != "Hello" {
+		t.Errorf("posts = %+v, want one post titled Hello", posts)
+	}
+	if len(charsets) != 1 || charsets[0] != "x-rot13" {
+		t.Errorf("CharsetReader called with %v, want [x-rot13]", charsets)
+	}
+
+	// Built-in charsets do not reach the custom reader
+	charsets = nil
+	if _, err := NewParser().WithCharsetReader(rot13).Parse(strings.NewReader(legacyDocument("windows-1252", "Caf\xe9", ""))); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(charsets) != 0 {
+		t.Errorf("CharsetReader called with %v for a built-in charset", charsets)
+	}
+}
+
+func TestParser_WithRecovery_UnsupportedCharset(t *testing.T) {
+	input := legacyDocument("shift_jis", "Title", "")
+	doc, err := NewParser().WithRecovery(true).ParseDocument(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if len(doc.Posts) != 1 || doc.Posts[0].TitleRendered != "Title" {
+		t.Errorf("posts = %+v, want one post titled Title", doc.Posts)
+	}
+	if len(doc.Diagnostics) != 1 || !strings.Contains(doc.Diagnostics[0].Message, `"shift_jis" as UTF-8`) {
+		t.Errorf("Diagnostics = %v, want the encoding read as UTF-8", doc.Diagnostics)
+	}
+}
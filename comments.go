@@ -0,0 +1,157 @@
+package wxr
+
+import "strings"
+
+// Comment represents a comment on a post, parsed from a wp:comment element.
+type Comment struct {
+	// ID is the WordPress comment ID.
+	ID int
+
+	// PostID is the ID of the post the comment belongs to.
+	PostID int
+
+	// ParentID is the ID of the comment this one replies to, or 0 for a top-level comment.
+	ParentID int
+
+	// Author is the display name given by the commenter.
+	Author string
+
+	// AuthorEmail is the commenter's email address.
+	AuthorEmail string
+
+	// AuthorURL is the commenter's website.
+	AuthorURL string
+
+	// AuthorIP is the IP address the comment was posted from.
+	AuthorIP string
+
+	// UserID is the ID of the registered user who wrote the comment, or 0 for guests.
+	UserID int
+
+	// Date is the comment date in RFC3339 format, taken from comment_date_gmt when
+	// available like Post.Date.
+	Date string
+
+	// LocalDate is the comment date in the site's time zone (comment_date), as exported.
+	LocalDate string
+
+	// Content is the comment text (HTML may be present).
+	Content string
+
+	// Approved is the moderation state: "1" for approved, "0" for pending,
+	// or "spam" and "trash".
+	Approved string
+
+	// Type is the comment type: "comment" for regular comments, "pingback",
+	// "trackback", or a custom type registered by a plugin.
+	Type string
+
+	// Meta contains the comment meta fields as key-value pairs.
+	Meta map[string]string
+
+	// MetaEntries are the meta fields as exported; see Post.MetaEntries.
+	MetaEntries []MetaEntry
+}
+
+// IsApproved reports whether the comment was approved for display.
+func (c Comment) IsApproved() bool {
+	return c.Approved == "1"
+}
+
+// CommentExtractor handles comment extraction from WXR items.
+type CommentExtractor struct{}
+
+// Extract extracts the comments of an item in export order.
+func (e *CommentExtractor) Extract(item *item) []Comment {
+	comments := make([]Comment, 0, len(item.Comments))
+	for _, c := range item.Comments {
+		commentType := strings.TrimSpace(c.Type)
+		if commentType == "" {
+			commentType = "comment"
+		}
+
+		date := c.DateGMT
+		if _, ok := parseWXRDate(date); !ok {
+			date = c.Date
+		}
+		if date != "" {
+			date = normalizeWXRDate(date)
+		}
+
+		comments = append(comments, Comment{
+			ID:          c.ID,
+			PostID:      item.PostID,
+			ParentID:    c.Parent,
+			Author:      strings.TrimSpace(c.Author),
+			AuthorEmail: strings.TrimSpace(c.AuthorEmail),
+			AuthorURL:   strings.TrimSpace(c.AuthorURL),
+			AuthorIP:    strings.TrimSpace(c.AuthorIP),
+			UserID:      c.UserID,
+			Date:        date,
+			LocalDate:   c.Date,
+			Content:     c.Content,
+			Approved:    strings.TrimSpace(c.Approved),
+			Type:        commentType,
+			Meta:        metaMap(c.Meta),
+			MetaEntries: metaEntries(c.Meta),
+		})
+	}
+	return comments
+}
+
+// CommentNode is a comment together with the replies to it.
+type CommentNode struct {
+	Comment
+
+	// Replies are the comments whose ParentID is this comment's ID, in input order.
+	Replies []*CommentNode
+}
+
+// BuildCommentTree arranges comments into reply threads using their ParentID.
+// It returns the top-level comments in input order. A comment whose parent is
+// missing from comments, or whose ancestry loops back to itself, is treated as
+// top-level so that no comment is lost. Replies go to the first comment with
+// their parent's ID; a comment with ID 0, such as one built by hand, or with
+// the ID of an earlier comment cannot have replies but is kept.
+func BuildCommentTree(comments []Comment) []*CommentNode {
+	nodes := make(map[int]*CommentNode, len(comments))
+	parents := make(map[int]int, len(comments))
+	order := make([]*CommentNode, 0, len(comments))
+	for _, c := range comments {
+		node := &CommentNode{Comment: c}
+		order = append(order, node)
+		if _, dup := nodes[c.ID]; !dup && c.ID != 0 {
+			nodes[c.ID] = node
+			parents[c.ID] = c.ParentID
+		}
+	}
+
+	roots := make([]*CommentNode, 0)
+	for _, node := range order {
+		parent, ok := nodes[node.ParentID]
+		// Only comments that can have replies can be part of a cycle
+		cycle := nodes[node.ID] == node && inCommentCycle(node.ID, parents)
+		if node.ParentID == 0 || !ok || cycle {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Replies = append(parent.Replies, node)
+	}
+	return roots
+}
+
+// inCommentCycle reports whether following parent links from id leads back to id.
+func inCommentCycle(id int, parents map[int]int) bool {
+	current := parents[id]
+	for steps := 0; current != 0 && steps <= len(parents); steps++ {
+		if current == id {
+			return true
+		}
+		next, ok := parents[current]
+		if !ok {
+			return false
+		}
+		current = next
+	}
+	return false
+}
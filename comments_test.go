@@ -0,0 +1,178 @@
+package wxr
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse_Comments(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Test Site</title>
+	<item>
+		<title><![CDATA[Discussed Post]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:comment>
+			<wp:comment_id>5</wp:comment_id>
+			<wp:comment_author><![CDATA[Ana]]></wp:comment_author>
+			<wp:comment_author_email><![CDATA[ana@example.com]]></wp:comment_author_email>
+			<wp:comment_author_url>https://ana.example.com</wp:comment_author_url>
+			<wp:comment_author_IP><![CDATA[192.0.2.1]]></wp:comment_author_IP>
+			<wp:comment_date><![CDATA[2025-02-01 09:00:00]]></wp:comment_date>
+			<wp:comment_date_gmt><![CDATA[2025-02-01 12:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[Great post!]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_type><![CDATA[]]></wp:comment_type>
+			<wp:comment_parent>0</wp:comment_parent>
+			<wp:comment_user_id>3</wp:comment_user_id>
+			<wp:commentmeta>
+				<wp:meta_key><![CDATA[rating]]></wp:meta_key>
+				<wp:meta_value><![CDATA[5]]></wp:meta_value>
+			</wp:commentmeta>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>6</wp:comment_id>
+			<wp:comment_author><![CDATA[Bruno]]></wp:comment_author>
+			<wp:comment_date_gmt><![CDATA[2025-02-01 13:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[Thanks, Ana]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[0]]></wp:comment_approved>
+			<wp:comment_parent>5</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>7</wp:comment_id>
+			<wp:comment_author><![CDATA[Other Blog]]></wp:comment_author>
+			<wp:comment_date><![CDATA[2025-02-02 08:00:00]]></wp:comment_date>
+			<wp:comment_date_gmt><![CDATA[0000-00-00 00:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[Linked here]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_type><![CDATA[pingback]]></wp:comment_type>
+		</wp:comment>
+	</item>
+</channel>
+</rss>`
+
+	posts, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+
+	comments := posts[0].Comments
+	if len(comments) != 3 {
+		t.Fatalf("expected 3 comments, got %d", len(comments))
+	}
+
+	first := comments[0]
+	if first.ID != 5 || first.PostID != 1 || first.Author != "Ana" || first.AuthorEmail != "ana@example.com" {
+		t.Errorf("unexpected comment: %+v", first)
+	}
+	if first.AuthorURL != "https://ana.example.com" || first.AuthorIP != "192.0.2.1" || first.UserID != 3 {
+		t.Errorf("unexpected comment author fields: %+v", first)
+	}
+	if first.Date != "2025-02-01T12:00:00Z" || first.LocalDate != "2025-02-01 09:00:00" {
+		t.Errorf("unexpected comment dates %q / %q", first.Date, first.LocalDate)
+	}
+	if first.Type != "comment" || !first.IsApproved() || first.Meta["rating"] != "5" {
+		t.Errorf("unexpected comment state: %+v", first)
+	}
+
+	if reply := comments[1]; reply.ParentID != 5 || reply.IsApproved() {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+	if ping := comments[2]; ping.Type != "pingback" || ping.Date != "2025-02-02T08:00:00Z" {
+		t.Errorf("expected pingback dated from comment_date, got %+v", ping)
+	}
+
+	tree := posts[0].CommentTree()
+	if len(tree) != 2 || tree[0].ID != 5 || tree[1].ID != 7 {
+		t.Fatalf("unexpected roots: %+v", tree)
+	}
+	if len(tree[0].Replies) != 1 || tree[0].Replies[0].ID != 6 {
+		t.Errorf("expected comment 6 to reply to 5, got %+v", tree[0].Replies)
+	}
+}
+
+func TestParse_NoComments(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title><![CDATA[Quiet Post]]></title>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+	posts, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if posts[0].Comments == nil {
+		t.Error("expected Comments to be initialized (empty slice)")
+	}
+}
+
+func TestBuildCommentTree(t *testing.T) {
+	comments := []Comment{
+		{ID: 1},
+		{ID: 2, ParentID: 1},
+		{ID: 3, ParentID: 2},
+		{ID: 4, ParentID: 99}, // parent not exported
+		{ID: 5, ParentID: 6},  // cycle
+		{ID: 6, ParentID: 5},
+		{ID: 7, ParentID: 1},
+	}
+
+	roots := BuildCommentTree(comments)
+
+	var ids []int
+	for _, root := range roots {
+		ids = append(ids, root.ID)
+	}
+	if !slices.Equal(ids, []int{1, 4, 5, 6}) {
+		t.Fatalf("expected roots [1 4 5 6], got %v", ids)
+	}
+
+	replies := roots[0].Replies
+	if len(replies) != 2 || replies[0].ID != 2 || replies[1].ID != 7 {
+		t.Fatalf("unexpected replies to 1: %+v", replies)
+	}
+	if len(replies[0].Replies) != 1 || replies[0].Replies[0].ID != 3 {
+		t.Errorf("expected 3 nested under 2, got %+v", replies[0].Replies)
+	}
+}
+
+func TestBuildCommentTree_RepeatedIDs(t *testing.T) {
+	comments := []Comment{
+		{ID: 1, Content: "first"},
+		{Content: "no ID"},
+		{Content: "no ID either"},
+		{ParentID: 1, Content: "reply without ID"},
+		{ID: 1, Content: "same ID"},
+		{ID: 2, ParentID: 1, Content: "reply"},
+	}
+
+	roots := BuildCommentTree(comments)
+
+	var contents []string
+	for _, root := range roots {
+		contents = append(contents, root.Content)
+	}
+	if want := []string{"first", "no ID", "no ID either", "same ID"}; !slices.Equal(contents, want) {
+		t.Fatalf("roots = %q, want %q", contents, want)
+	}
+	if replies := roots[0].Replies; len(replies) != 2 || replies[0].Content != "reply without ID" || replies[1].ID != 2 {
+		t.Errorf("unexpected replies to the first comment: %+v", replies)
+	}
+}
@@ -9,6 +9,18 @@ import (
 // normalizeWXRDate attempts to normalize WordPress date strings to RFC3339 format.
 // WordPress WXR dates are typically in formats like "2025-06-01 14:00:51" or RFC822-like formats.
 func normalizeWXRDate(dateStr string) string {
+	if t, ok := parseWXRDate(dateStr); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	// Return as-is if we can't parse it
+	return dateStr
+}
+
+// parseWXRDate parses a WordPress date string. Dates without a zone are read as UTC.
+// It reports false for unparseable values, including WordPress's zero date
+// "0000-00-00 00:00:00" used for unscheduled drafts.
+func parseWXRDate(dateStr string) (time.Time, bool) {
 	// Try common WordPress date formats
 	formats := []string{
 		time.RFC3339,
@@ -22,7 +34,7 @@ func normalizeWXRDate(dateStr string) string {
 
 	for _, format := range formats {
 		if t, err := time.Parse(format, dateStr); err == nil {
-			return t.Format(time.RFC3339)
+			return t, true
 		}
 	}
 
@@ -33,11 +45,10 @@ func normalizeWXRDate(dateStr string) string {
 		timePart := parts[1]
 		if combined := fmt.Sprintf("%sT%sZ", datePart, timePart); len(combined) > 0 {
 			if t, err := time.Parse("2006-01-02T15:04:05Z", combined); err == nil {
-				return t.Format(time.RFC3339)
+				return t, true
 			}
 		}
 	}
 
-	// Return as-is if we can't parse it
-	return dateStr
+	return time.Time{}, false
 }
@@ -0,0 +1,109 @@
+package wxr
+
+import (
+	"context"
+	"io"
+)
+
+// Document is the structured result of parsing a whole WXR export.
+type Document struct {
+	// Site is the information about the exported site declared by the channel.
+	Site Site
+
+	// Posts are the items accepted by the parser's filter, transformed into posts.
+	Posts []Post
+
+	// Attachments are the media library items of the export, whether or not the
+	// filter selects them as posts.
+	Attachments []Attachment
+
+	// Authors are the users declared at the channel level (wp:author), in declaration order.
+	Authors []Author
+
+	// Taxonomies are the terms declared at the channel level (wp:category, wp:tag
+	// and wp:term), keyed by taxonomy name such as "category" or "post_tag".
+	Taxonomies map[string]*Taxonomy
+
+	// Diagnostics describe what was repaired or dropped in recovery mode and by
+	// mojibake repair. See Parser.WithRecovery and Parser.WithMojibakeRepair.
+	Diagnostics []Diagnostic
+}
+
+// ParseDocument parses a WordPress WXR XML export file into a Document holding
+// the posts together with the channel-level data of the export.
+// Posts are selected and transformed exactly as ParseWithContext does. If the
+// context is cancelled while posts are being built, the Document holds the posts
+// parsed so far and the context error is returned with it.
+func (p *Parser) ParseDocument(ctx context.Context, r io.Reader) (*Document, error) {
+	// Check context before starting
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	p.logger.Printf("Starting WXR parsing")
+
+	wxrDoc, err := p.decodeXML(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.buildDocument(ctx, wxrDoc)
+}
+
+// buildDocument builds the Document of a decoded WXR document.
+func (p *Parser) buildDocument(ctx context.Context, wxrDoc *wxr) (*Document, error) {
+	// Check context after decoding
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	p.logger.Printf("Parsed WXR document, found %d items", len(wxrDoc.Channel.Items))
+	for _, d := range wxrDoc.Channel.Diagnostics {
+		p.logger.Printf("Worked around bad input: %v", d)
+	}
+
+	// Build attachment (ID -> URL, parent -> URLs), term and author lookups
+	index := buildChannelIndex(wxrDoc.Channel)
+
+	doc := &Document{
+		Site:        buildSite(wxrDoc.Channel),
+		Posts:       make([]Post, 0),
+		Authors:     index.authors,
+		Attachments: buildAttachments(wxrDoc.Channel),
+		Taxonomies:  index.taxonomies,
+		Diagnostics: wxrDoc.Channel.Diagnostics,
+	}
+	stats := newParseStats()
+
+	for i := range wxrDoc.Channel.Items {
+		// Check context periodically during parsing
+		select {
+		case <-ctx.Done():
+			p.logger.Printf("Parsing cancelled, returning %d posts parsed so far", len(doc.Posts))
+			return doc, ctx.Err()
+		default:
+		}
+
+		item := &wxrDoc.Channel.Items[i]
+		if !p.includeItem(item, stats) {
+			continue
+		}
+
+		doc.Posts = append(doc.Posts, p.buildPost(item, index))
+		stats.posts++
+	}
+
+	stats.log(p.logger)
+
+	return doc, nil
+}
+
+// ParseDocument is a convenience function that parses a WXR file into a Document
+// using the default parser.
+func ParseDocument(ctx context.Context, r io.Reader) (*Document, error) {
+	parser := NewParser()
+	return parser.ParseDocument(ctx, r)
+}
@@ -0,0 +1,521 @@
+package wxr
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder writes WXR 1.2 documents that can be imported into WordPress with the
+// official importer.
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewEncoder creates an Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Encode writes doc as a complete WXR 1.2 document: the site information, the
+// authors, the terms of every taxonomy, then the posts followed by the
+// attachments that are not among the posts.
+//
+// Encoding a parsed Document and parsing the result again yields the same
+// values for every field the package reads, except that Site.WXRVersion and
+// Site.NamespaceVersion are always "1.2". Posts, attachments and comments are
+// written from the values as exported: ContentRaw, ExcerptRaw and MetaEntries,
+// with repeated and empty meta fields. Values built by hand, whose MetaEntries
+// is nil, are written from ContentRendered, Excerpt and Meta instead. Local
+// dates are written as they were read, or in UTC when they are not set.
+// Derived fields such as FeaturedImage are not written; the meta fields they
+// come from are. The unmapped elements in Site.Extra, Post.Extra and
+// Attachment.Extra are written after the mapped ones.
+func (e *Encoder) Encode(doc *Document) error {
+	e.writeHeader(doc.Site)
+	for _, a := range doc.Authors {
+		e.writeAuthor(a)
+	}
+	e.writeTaxonomies(doc.Taxonomies)
+
+	attachments := make(map[int]Attachment, len(doc.Attachments))
+	for _, a := range doc.Attachments {
+		attachments[a.ID] = a
+	}
+	written := make(map[int]bool, len(doc.Posts))
+	for _, p := range doc.Posts {
+		var attachmentURL string
+		if p.Type == "attachment" {
+			attachmentURL = attachments[p.ID].URL
+		}
+		e.writePost(p, attachmentURL)
+		written[p.ID] = true
+	}
+	for _, a := range doc.Attachments {
+		if !written[a.ID] {
+			e.writeAttachment(a)
+		}
+	}
+
+	e.printf("</channel>\n</rss>\n")
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	if e.err != nil {
+		return fmt.Errorf("wxr: failed to write WXR XML: %w", e.err)
+	}
+	return nil
+}
+
+func (e *Encoder) writeHeader(site Site) {
+	e.printf("<?xml version=\"1.0\" encoding=\"UTF-8\" ?>\n")
+	e.printf("<rss version=\"2.0\"\n" +
+		"\txmlns:excerpt=\"http://wordpress.org/export/1.2/excerpt/\"\n" +
+		"\txmlns:content=\"http://purl.org/rss/1.0/modules/content/\"\n" +
+		"\txmlns:wfw=\"http://wellformedweb.org/CommentAPI/\"\n" +
+		"\txmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n" +
+		"\txmlns:wp=\"http://wordpress.org/export/1.2/\"\n>\n\n")
+	e.printf("<channel>\n")
+	e.text(1, "title", site.Title)
+	e.text(1, "link", site.Link)
+	e.text(1, "description", site.Description)
+	if site.PubDate != "" {
+		e.text(1, "pubDate", rssDate(site.PubDate))
+	}
+	e.text(1, "language", site.Language)
+	e.text(1, "wp:wxr_version", "1.2")
+	e.text(1, "wp:base_site_url", site.BaseSiteURL)
+	e.text(1, "wp:base_blog_url", site.BaseBlogURL)
+	if site.Generator != "" {
+		e.text(1, "generator", site.Generator)
+	}
+	e.writeNodes(1, site.Extra)
+	e.printf("\n")
+}
+
+func (e *Encoder) writeAuthor(a Author) {
+	e.printf("\t<wp:author>")
+	e.text(0, "wp:author_id", strconv.Itoa(a.ID))
+	e.cdata(0, "wp:author_login", a.Login)
+	e.cdata(0, "wp:author_email", a.Email)
+	e.cdata(0, "wp:author_display_name", a.DisplayName)
+	e.cdata(0, "wp:author_first_name", a.FirstName)
+	e.cdata(0, "wp:author_last_name", a.LastName)
+	e.printf("</wp:author>\n")
+}
+
+// writeTaxonomies writes categories as wp:category, tags as wp:tag and the terms
+// of other taxonomies as wp:term, like WordPress's exporter.
+func (e *Encoder) writeTaxonomies(taxonomies map[string]*Taxonomy) {
+	for _, name := range taxonomyOrder(taxonomies) {
+		for _, t := range taxonomies[name].Terms {
+			switch name {
+			case "category":
+				e.printf("\t<wp:category>")
+				e.text(0, "wp:term_id", strconv.Itoa(t.ID))
+				e.cdata(0, "wp:category_nicename", t.Slug)
+				e.cdata(0, "wp:category_parent", t.Parent)
+				e.cdata(0, "wp:cat_name", t.Name)
+				e.cdataIfSet(0, "wp:category_description", t.Description)
+				e.writeMeta(0, "wp:termmeta", sortedMeta(t.Meta))
+				e.printf("</wp:category>\n")
+			case "post_tag":
+				e.printf("\t<wp:tag>")
+				e.text(0, "wp:term_id", strconv.Itoa(t.ID))
+				e.cdata(0, "wp:tag_slug", t.Slug)
+				e.cdata(0, "wp:tag_name", t.Name)
+				e.cdataIfSet(0, "wp:tag_description", t.Description)
+				e.writeMeta(0, "wp:termmeta", sortedMeta(t.Meta))
+				e.printf("</wp:tag>\n")
+			default:
+				e.printf("\t<wp:term>")
+				e.text(0, "wp:term_id", strconv.Itoa(t.ID))
+				e.cdata(0, "wp:term_taxonomy", name)
+				e.cdata(0, "wp:term_slug", t.Slug)
+				e.cdata(0, "wp:term_parent", t.Parent)
+				e.cdata(0, "wp:term_name", t.Name)
+				e.cdataIfSet(0, "wp:term_description", t.Description)
+				e.writeMeta(0, "wp:termmeta", sortedMeta(t.Meta))
+				e.printf("</wp:term>\n")
+			}
+		}
+	}
+}
+
+// taxonomyOrder returns the taxonomy names with categories and tags first and
+// the others in alphabetical order.
+func taxonomyOrder[T any](taxonomies map[string]T) []string {
+	names := make([]string, 0, len(taxonomies))
+	for name := range taxonomies {
+		names = append(names, name)
+	}
+	rank := func(name string) int {
+		switch name {
+		case "category":
+			return 0
+		case "post_tag":
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if ri, rj := rank(names[i]), rank(names[j]); ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// writePost writes a post as an item. attachmentURL is set for attachments.
+func (e *Encoder) writePost(p Post, attachmentURL string) {
+	creator := p.AuthorLogin
+	if creator == "" {
+		creator = p.Author
+	}
+	postType := p.Type
+	if postType == "" {
+		postType = "post"
+	}
+	content, excerpt, meta := p.ContentRaw, p.ExcerptRaw, p.MetaEntries
+	if meta == nil {
+		content, excerpt = p.ContentRendered, p.Excerpt
+		fields := p.Meta
+		if p.PageTemplate != "" && fields["_wp_page_template"] == "" {
+			fields = withMeta(fields, "_wp_page_template", p.PageTemplate)
+		}
+		meta = sortedMeta(fields)
+	}
+
+	e.printf("\n\t<item>\n")
+	e.cdata(2, "title", p.TitleRendered)
+	e.text(2, "link", p.Link)
+	if p.Date != "" {
+		e.text(2, "pubDate", rssDate(p.Date))
+	}
+	e.cdata(2, "dc:creator", creator)
+	e.printf("\t\t<guid isPermaLink=\"false\">%s</guid>\n", escapeText(p.GUID))
+	e.writeDescription(p.RSSDescription)
+	e.cdata(2, "content:encoded", content)
+	e.cdata(2, "excerpt:encoded", excerpt)
+	e.text(2, "wp:post_id", strconv.Itoa(p.ID))
+	e.writeDates(p.Date, p.LocalDate, "wp:post_date", "wp:post_date_gmt")
+	e.writeDates(p.ModifiedDate, p.LocalModifiedDate, "wp:post_modified", "wp:post_modified_gmt")
+	e.cdata(2, "wp:post_name", p.Slug)
+	e.cdata(2, "wp:status", p.Status)
+	e.text(2, "wp:post_parent", strconv.Itoa(p.ParentID))
+	e.text(2, "wp:menu_order", strconv.Itoa(p.MenuOrder))
+	e.cdata(2, "wp:post_type", postType)
+	if attachmentURL != "" {
+		e.cdata(2, "wp:attachment_url", attachmentURL)
+	}
+	e.writeTerms(p)
+	e.writeMeta(2, "wp:postmeta", meta)
+	for _, c := range p.Comments {
+		e.writeComment(c)
+	}
+	e.writeNodes(2, p.Extra)
+	e.printf("\t</item>\n")
+}
+
+func (e *Encoder) writeAttachment(a Attachment) {
+	// Attachments built by hand have no meta fields; store the path and the
+	// alternative text where WordPress keeps them.
+	meta := a.MetaEntries
+	if meta == nil {
+		fields := a.Meta
+		if fields == nil {
+			fields = make(map[string]string)
+			if a.RelativePath != "" {
+				fields["_wp_attached_file"] = a.RelativePath
+			}
+			if a.AltText != "" {
+				fields["_wp_attachment_image_alt"] = a.AltText
+			}
+		}
+		meta = sortedMeta(fields)
+	}
+
+	e.printf("\n\t<item>\n")
+	e.cdata(2, "title", a.Title)
+	if a.Date != "" {
+		e.text(2, "pubDate", rssDate(a.Date))
+	}
+	e.printf("\t\t<guid isPermaLink=\"false\">%s</guid>\n", escapeText(a.URL))
+	e.writeDescription(a.RSSDescription)
+	e.cdata(2, "content:encoded", a.Description)
+	e.cdata(2, "excerpt:encoded", a.Caption)
+	e.text(2, "wp:post_id", strconv.Itoa(a.ID))
+	e.writeDates(a.Date, a.LocalDate, "wp:post_date", "wp:post_date_gmt")
+	e.cdata(2, "wp:post_name", a.Slug)
+	e.cdata(2, "wp:status", "inherit")
+	e.text(2, "wp:post_parent", strconv.Itoa(a.ParentID))
+	e.text(2, "wp:menu_order", "0")
+	e.cdata(2, "wp:post_type", "attachment")
+	e.cdata(2, "wp:attachment_url", a.URL)
+	e.writeMeta(2, "wp:postmeta", meta)
+	e.writeNodes(2, a.Extra)
+	e.printf("\t</item>\n")
+}
+
+// writeTerms writes the terms of a post as category elements. Categories and
+// Tags are used for posts whose Terms do not include those taxonomies.
+func (e *Encoder) writeTerms(p Post) {
+	write := func(taxonomy, name, slug string) {
+		e.printf("\t\t<category domain=\"%s\"", escapeText(taxonomy))
+		if slug != "" {
+			e.printf(" nicename=\"%s\"", escapeText(slug))
+		}
+		e.printf(">%s</category>\n", cdataSection(name))
+	}
+
+	if _, ok := p.Terms["category"]; !ok {
+		for _, name := range p.Categories {
+			write("category", name, "")
+		}
+	}
+	if _, ok := p.Terms["post_tag"]; !ok {
+		for _, name := range p.Tags {
+			write("post_tag", name, "")
+		}
+	}
+	for _, taxonomy := range taxonomyOrder(p.Terms) {
+		for _, t := range p.Terms[taxonomy] {
+			write(taxonomy, t.Name, t.Slug)
+		}
+	}
+}
+
+func (e *Encoder) writeComment(c Comment) {
+	localDate := c.LocalDate
+	if localDate == "" {
+		localDate = wxrDate(c.Date)
+	}
+
+	e.printf("\t\t<wp:comment>\n")
+	e.text(3, "wp:comment_id", strconv.Itoa(c.ID))
+	e.cdata(3, "wp:comment_author", c.Author)
+	e.cdata(3, "wp:comment_author_email", c.AuthorEmail)
+	e.cdata(3, "wp:comment_author_url", c.AuthorURL)
+	e.cdata(3, "wp:comment_author_IP", c.AuthorIP)
+	e.cdata(3, "wp:comment_date", localDate)
+	e.cdata(3, "wp:comment_date_gmt", wxrDate(c.Date))
+	e.cdata(3, "wp:comment_content", c.Content)
+	e.cdata(3, "wp:comment_approved", c.Approved)
+	e.cdata(3, "wp:comment_type", c.Type)
+	e.text(3, "wp:comment_parent", strconv.Itoa(c.ParentID))
+	e.text(3, "wp:comment_user_id", strconv.Itoa(c.UserID))
+	meta := c.MetaEntries
+	if meta == nil {
+		meta = sortedMeta(c.Meta)
+	}
+	e.writeMeta(3, "wp:commentmeta", meta)
+	e.printf("\t\t</wp:comment>\n")
+}
+
+// writeDescription writes the description element of an item, empty like
+// WordPress's exporter unless description is set.
+func (e *Encoder) writeDescription(description string) {
+	if description == "" {
+		e.printf("\t\t<description></description>\n")
+		return
+	}
+	e.cdata(2, "description", description)
+}
+
+// writeDates writes an RFC3339 date as a local and a GMT element. The local
+// element holds localDate, or the UTC time when it is empty, since the site's
+// time zone is not known.
+func (e *Encoder) writeDates(date, localDate, local, gmt string) {
+	if date == "" {
+		return
+	}
+	if localDate == "" {
+		localDate = wxrDate(date)
+	}
+	e.cdata(2, local, localDate)
+	e.cdata(2, gmt, wxrDate(date))
+}
+
+// writeMeta writes meta fields in order.
+func (e *Encoder) writeMeta(depth int, element string, meta []MetaEntry) {
+	for _, m := range meta {
+		if depth > 0 {
+			e.printf("%s", strings.Repeat("\t", depth))
+		}
+		e.printf("<%s>", element)
+		e.cdata(0, "wp:meta_key", m.Key)
+		e.cdata(0, "wp:meta_value", m.Value)
+		e.printf("</%s>", element)
+		if depth > 0 {
+			e.printf("\n")
+		}
+	}
+}
+
+// namespacePrefixes are the prefixes WXR uses, keyed by namespace URI. The
+// encoder declares them on the root element.
+var namespacePrefixes = map[string]string{
+	"http://wordpress.org/export/1.2/excerpt/": "excerpt",
+	"http://purl.org/rss/1.0/modules/content/": "content",
+	"http://wellformedweb.org/CommentAPI/":     "wfw",
+	"http://purl.org/dc/elements/1.1/":         "dc",
+	wpNamespace:                                "wp",
+	"http://www.w3.org/XML/1998/namespace":     "xml",
+}
+
+// writeNodes writes elements the package does not map, one per line.
+func (e *Encoder) writeNodes(depth int, nodes Nodes) {
+	for _, n := range nodes {
+		e.printf("%s", strings.Repeat("\t", depth))
+		e.writeNode(n, "")
+		e.printf("\n")
+	}
+}
+
+// writeNode writes n and its children. Elements in one of the root namespaces
+// use its prefix; others declare their namespace as the default namespace,
+// which defaultNS holds for the enclosing element.
+func (e *Encoder) writeNode(n Node, defaultNS string) {
+	name := n.Name.Local
+	var attrs strings.Builder
+	if prefix, ok := namespacePrefixes[n.Name.Space]; ok && n.Name.Space != "" {
+		name = prefix + ":" + name
+	} else if n.Name.Space != defaultNS {
+		fmt.Fprintf(&attrs, " xmlns=\"%s\"", escapeText(n.Name.Space))
+		defaultNS = n.Name.Space
+	}
+	declared := 0
+	for _, a := range n.Attrs {
+		attr := a.Name.Local
+		if a.Name.Space != "" {
+			prefix, ok := namespacePrefixes[a.Name.Space]
+			if !ok {
+				declared++
+				prefix = "ns" + strconv.Itoa(declared)
+				fmt.Fprintf(&attrs, " xmlns:%s=\"%s\"", prefix, escapeText(a.Name.Space))
+			}
+			attr = prefix + ":" + attr
+		}
+		fmt.Fprintf(&attrs, " %s=\"%s\"", attr, escapeText(a.Value))
+	}
+
+	e.printf("<%s%s>", name, attrs.String())
+	if strings.TrimSpace(n.Text) == "" {
+		e.printf("%s", n.Text)
+	} else {
+		e.printf("%s", cdataSection(n.Text))
+	}
+	for _, child := range n.Children {
+		e.writeNode(child, defaultNS)
+	}
+	e.printf("</%s>", name)
+}
+
+// sortedMeta returns the fields of meta in key order.
+func sortedMeta(meta map[string]string) []MetaEntry {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]MetaEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = MetaEntry{Key: key, Value: meta[key]}
+	}
+	return entries
+}
+
+// withMeta returns a copy of meta with an additional field.
+func withMeta(meta map[string]string, key, value string) map[string]string {
+	out := make(map[string]string, len(meta)+1)
+	for k, v := range meta {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+// text writes an element with escaped character data. A depth of 0 writes the
+// element inline, without indentation or newline.
+func (e *Encoder) text(depth int, name, value string) {
+	e.element(depth, name, escapeText(value))
+}
+
+// cdata writes an element with its value in a CDATA section.
+func (e *Encoder) cdata(depth int, name, value string) {
+	e.element(depth, name, cdataSection(value))
+}
+
+// cdataIfSet writes an element with a CDATA section unless value is empty.
+func (e *Encoder) cdataIfSet(depth int, name, value string) {
+	if value != "" {
+		e.cdata(depth, name, value)
+	}
+}
+
+func (e *Encoder) element(depth int, name, content string) {
+	if depth > 0 {
+		e.printf("%s<%s>%s</%s>\n", strings.Repeat("\t", depth), name, content, name)
+	} else {
+		e.printf("<%s>%s</%s>", name, content, name)
+	}
+}
+
+func (e *Encoder) printf(format string, args ...any) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, args...)
+}
+
+// cdataSection wraps s in a CDATA section, splitting it around "]]>" like
+// WordPress's wxr_cdata(). Empty values are written as an empty section.
+func cdataSection(s string) string {
+	s = xmlSafe(s)
+	return "<![CDATA[" + strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
+
+// escapeText escapes s for use as character data or an attribute value.
+func escapeText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(xmlSafe(s)))
+	return b.String()
+}
+
+// xmlSafe replaces invalid UTF-8 and removes the characters XML 1.0 does not
+// allow, which would make the document unreadable.
+func xmlSafe(s string) string {
+	s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r <= 0xD7FF) ||
+			(r >= 0xE000 && r <= 0xFFFD) || r >= 0x10000 {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// wxrDate formats an RFC3339 date as a WordPress date in UTC. Other values,
+// such as the zero date "0000-00-00 00:00:00", are returned unchanged.
+func wxrDate(date string) string {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t.UTC().Format("2006-01-02 15:04:05")
+	}
+	return date
+}
+
+// rssDate formats an RFC3339 date for an RSS pubDate element.
+func rssDate(date string) string {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t.UTC().Format(time.RFC1123Z)
+	}
+	return date
+}
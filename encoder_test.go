@@ -0,0 +1,252 @@
+package wxr
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const encoderXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Diário &amp; Cia</title>
+	<link>https://example.com</link>
+	<description>News</description>
+	<pubDate>Tue, 02 Jan 2024 15:04:05 +0000</pubDate>
+	<language>pt-BR</language>
+	<wp:wxr_version>1.2</wp:wxr_version>
+	<wp:base_site_url>https://example.com</wp:base_site_url>
+	<wp:base_blog_url>https://example.com</wp:base_blog_url>
+	<wp:author><wp:author_id>2</wp:author_id><wp:author_login><![CDATA[maria]]></wp:author_login><wp:author_email><![CDATA[maria@example.com]]></wp:author_email><wp:author_display_name><![CDATA[Maria Silva]]></wp:author_display_name><wp:author_first_name><![CDATA[Maria]]></wp:author_first_name><wp:author_last_name><![CDATA[Silva]]></wp:author_last_name></wp:author>
+	<wp:category><wp:term_id>3</wp:term_id><wp:category_nicename><![CDATA[news]]></wp:category_nicename><wp:category_parent><![CDATA[]]></wp:category_parent><wp:cat_name><![CDATA[News]]></wp:cat_name></wp:category>
+	<wp:category><wp:term_id>4</wp:term_id><wp:category_nicename><![CDATA[politics]]></wp:category_nicename><wp:category_parent><![CDATA[news]]></wp:category_parent><wp:cat_name><![CDATA[Politics]]></wp:cat_name><wp:category_description><![CDATA[Elections & more]]></wp:category_description></wp:category>
+	<wp:tag><wp:term_id>5</wp:term_id><wp:tag_slug><![CDATA[go]]></wp:tag_slug><wp:tag_name><![CDATA[Go]]></wp:tag_name></wp:tag>
+	<wp:term><wp:term_id>6</wp:term_id><wp:term_taxonomy><![CDATA[series]]></wp:term_taxonomy><wp:term_slug><![CDATA[intro]]></wp:term_slug><wp:term_parent><![CDATA[]]></wp:term_parent><wp:term_name><![CDATA[Intro]]></wp:term_name><wp:termmeta><wp:meta_key><![CDATA[color]]></wp:meta_key><wp:meta_value><![CDATA[blue]]></wp:meta_value></wp:termmeta></wp:term>
+	<generator>https://wordpress.org/?v=6.4.2</generator>
+	<item>
+		<title><![CDATA[Hello <em>world</em>]]></title>
+		<link>https://example.com/hello/</link>
+		<pubDate>Fri, 05 Jan 2024 10:00:00 +0000</pubDate>
+		<dc:creator><![CDATA[maria]]></dc:creator>
+		<guid isPermaLink="false">https://example.com/?p=1</guid>
+		<description>A first post</description>
+		<content:encoded><![CDATA[<p>Use <code>a[b[0]]]]><![CDATA[></code> carefully.</p>]]></content:encoded>
+		<excerpt:encoded><![CDATA[Short]]></excerpt:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_date><![CDATA[2024-01-05 07:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[2024-01-05 10:00:00]]></wp:post_date_gmt>
+		<wp:post_modified><![CDATA[2024-01-06 08:00:00]]></wp:post_modified>
+		<wp:post_modified_gmt><![CDATA[2024-01-06 11:00:00]]></wp:post_modified_gmt>
+		<wp:post_name><![CDATA[hello]]></wp:post_name>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_parent>0</wp:post_parent>
+		<wp:menu_order>0</wp:menu_order>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<category domain="category" nicename="politics"><![CDATA[Politics]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+		<category domain="series" nicename="intro"><![CDATA[Intro]]></category>
+		<wp:postmeta><wp:meta_key><![CDATA[_thumbnail_id]]></wp:meta_key><wp:meta_value><![CDATA[10]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[views]]></wp:meta_key><wp:meta_value><![CDATA[a:1:{s:5:"total";i:42;}]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[_wp_old_slug]]></wp:meta_key><wp:meta_value><![CDATA[old-a]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[_wp_old_slug]]></wp:meta_key><wp:meta_value><![CDATA[old-b]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[empty_flag]]></wp:meta_key><wp:meta_value><![CDATA[]]></wp:meta_value></wp:postmeta>
+		<wp:comment>
+			<wp:comment_id>7</wp:comment_id>
+			<wp:comment_author><![CDATA[Ana]]></wp:comment_author>
+			<wp:comment_author_email><![CDATA[ana@example.com]]></wp:comment_author_email>
+			<wp:comment_author_url><![CDATA[]]></wp:comment_author_url>
+			<wp:comment_author_IP><![CDATA[127.0.0.1]]></wp:comment_author_IP>
+			<wp:comment_date><![CDATA[2024-01-05 08:00:00]]></wp:comment_date>
+			<wp:comment_date_gmt><![CDATA[2024-01-05 11:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[Nice!]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_type><![CDATA[comment]]></wp:comment_type>
+			<wp:comment_parent>0</wp:comment_parent>
+			<wp:comment_user_id>0</wp:comment_user_id>
+			<wp:commentmeta><wp:meta_key><![CDATA[rating]]></wp:meta_key><wp:meta_value><![CDATA[5]]></wp:meta_value></wp:commentmeta>
+			<wp:commentmeta><wp:meta_key><![CDATA[akismet_history]]></wp:meta_key><wp:meta_value><![CDATA[]]></wp:meta_value></wp:commentmeta>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>8</wp:comment_id>
+			<wp:comment_author><![CDATA[Maria Silva]]></wp:comment_author>
+			<wp:comment_date><![CDATA[2024-01-05 09:00:00]]></wp:comment_date>
+			<wp:comment_date_gmt><![CDATA[2024-01-05 12:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[Thanks]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_type><![CDATA[comment]]></wp:comment_type>
+			<wp:comment_parent>7</wp:comment_parent>
+			<wp:comment_user_id>2</wp:comment_user_id>
+		</wp:comment>
+	</item>
+	<item>
+		<title><![CDATA[About]]></title>
+		<dc:creator><![CDATA[maria]]></dc:creator>
+		<content:encoded><![CDATA[About us
+Second line]]></content:encoded>
+		<excerpt:encoded><![CDATA[]]></excerpt:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_date><![CDATA[2023-12-31 21:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[2024-01-01 00:00:00]]></wp:post_date_gmt>
+		<wp:post_name><![CDATA[about]]></wp:post_name>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_parent>0</wp:post_parent>
+		<wp:menu_order>3</wp:menu_order>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+		<wp:postmeta><wp:meta_key><![CDATA[_wp_page_template]]></wp:meta_key><wp:meta_value><![CDATA[full-width.php]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[subtitulo]]></wp:meta_key><wp:meta_value><![CDATA[A subtitle]]></wp:meta_value></wp:postmeta>
+	</item>
+	<item>
+		<title><![CDATA[Sunset]]></title>
+		<excerpt:encoded><![CDATA[Sunset over the bay]]></excerpt:encoded>
+		<description>Taken from the pier</description>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_date><![CDATA[2024-01-05 07:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[2024-01-05 10:00:00]]></wp:post_date_gmt>
+		<wp:post_parent>1</wp:post_parent>
+		<wp:post_name><![CDATA[sunset]]></wp:post_name>
+		<wp:post_type><![CDATA[attachment]]></wp:post_type>
+		<wp:status><![CDATA[inherit]]></wp:status>
+		<wp:attachment_url><![CDATA[https://example.com/wp-content/uploads/2024/01/sunset.jpg]]></wp:attachment_url>
+		<wp:postmeta><wp:meta_key><![CDATA[_wp_attached_file]]></wp:meta_key><wp:meta_value><![CDATA[2024/01/sunset.jpg]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[_wp_attachment_image_alt]]></wp:meta_key><wp:meta_value><![CDATA[Orange sky]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[_edit_lock]]></wp:meta_key><wp:meta_value><![CDATA[null]]></wp:meta_value></wp:postmeta>
+		<wp:postmeta><wp:meta_key><![CDATA[_wp_attachment_metadata]]></wp:meta_key><wp:meta_value><![CDATA[a:3:{s:5:"width";i:1200;s:6:"height";i:800;s:5:"sizes";a:1:{s:9:"thumbnail";a:4:{s:4:"file";s:18:"sunset-150x150.jpg";s:5:"width";i:150;s:6:"height";i:150;s:9:"mime-type";s:10:"image/jpeg";}}}]]></wp:meta_value></wp:postmeta>
+	</item>
+</channel>
+</rss>`
+
+func TestEncoder_RoundTrip(t *testing.T) {
+	parser := NewParser().WithFilter(NewContentFilter()).WithAutoP(true)
+	doc, err := parser.ParseDocument(context.Background(), strings.NewReader(encoderXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if len(doc.Posts) != 2 || len(doc.Attachments) != 1 {
+		t.Fatalf("unexpected fixture: %d posts, %d attachments", len(doc.Posts), len(doc.Attachments))
+	}
+	if page := doc.Posts[1]; page.Excerpt != "A subtitle" || !strings.HasPrefix(page.ContentRendered, "<p>") {
+		t.Fatalf("unexpected fixture: page excerpt %q, content %q", page.Excerpt, page.ContentRendered)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(doc); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "<![CDATA[<p>Use <code>a[b[0]]]]><![CDATA[></code> carefully.</p>]]>") {
+		t.Errorf("expected ]]> to be split across CDATA sections, got:\n%s", buf.String())
+	}
+	for _, want := range []string{
+		"<wp:post_date><![CDATA[2024-01-05 07:00:00]]></wp:post_date>",
+		"<wp:post_date_gmt><![CDATA[2024-01-05 10:00:00]]></wp:post_date_gmt>",
+		"<wp:post_modified><![CDATA[2024-01-06 08:00:00]]></wp:post_modified>",
+		"<description><![CDATA[A first post]]></description>",
+		"<description><![CDATA[Taken from the pier]]></description>",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in the encoded document", want)
+		}
+	}
+
+	// Content, excerpts and meta are written as exported, not as derived
+	for _, want := range []string{
+		"<wp:meta_value><![CDATA[old-a]]></wp:meta_value>",
+		"<wp:meta_value><![CDATA[old-b]]></wp:meta_value>",
+		"<wp:meta_key><![CDATA[empty_flag]]></wp:meta_key><wp:meta_value><![CDATA[]]></wp:meta_value>",
+		"<wp:meta_key><![CDATA[akismet_history]]></wp:meta_key>",
+		"<wp:meta_key><![CDATA[_edit_lock]]></wp:meta_key><wp:meta_value><![CDATA[null]]></wp:meta_value>",
+		"<content:encoded><![CDATA[About us\nSecond line]]></content:encoded>",
+		"<excerpt:encoded><![CDATA[]]></excerpt:encoded>\n\t\t<wp:post_id>2</wp:post_id>",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in the encoded document", want)
+		}
+	}
+
+	again, err := NewParser().WithFilter(NewContentFilter()).WithAutoP(true).ParseDocument(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ParseDocument() of encoded document error = %v", err)
+	}
+	if !reflect.DeepEqual(doc, again) {
+		t.Errorf("round trip changed the document\nbefore: %+v\nafter:  %+v", doc, again)
+	}
+	if again.Posts[0].FeaturedImage != "https://example.com/wp-content/uploads/2024/01/sunset.jpg" {
+		t.Errorf("FeaturedImage = %q", again.Posts[0].FeaturedImage)
+	}
+}
+
+func TestEncoder_Attachments(t *testing.T) {
+	doc := &Document{
+		Site: Site{Title: "Site", Link: "https://example.com"},
+		Posts: []Post{{
+			ID:              1,
+			TitleRendered:   "Post",
+			ContentRendered: "Content",
+			Status:          "publish",
+			Date:            "2024-01-05T07:00:00-03:00",
+			Categories:      []string{"News"},
+			Tags:            []string{"go"},
+		}},
+		Attachments: []Attachment{{
+			ID:           5,
+			ParentID:     1,
+			Title:        "Photo",
+			URL:          "https://example.com/wp-content/uploads/photo.jpg",
+			RelativePath: "photo.jpg",
+			AltText:      "A photo",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(doc); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	// Without a local date, both dates are written in UTC
+	if want := "<wp:post_date><![CDATA[2024-01-05 10:00:00]]></wp:post_date>"; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in the encoded document", want)
+	}
+	parsed, err := ParseDocument(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+
+	if parsed.Site.WXRVersion != "1.2" {
+		t.Errorf("WXRVersion = %q", parsed.Site.WXRVersion)
+	}
+	if len(parsed.Posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(parsed.Posts))
+	}
+	post := parsed.Posts[0]
+	if post.Type != "post" || !reflect.DeepEqual(post.Categories, []string{"News"}) || !reflect.DeepEqual(post.Tags, []string{"go"}) {
+		t.Errorf("unexpected post: %+v", post)
+	}
+	if post.FeaturedImage != doc.Attachments[0].URL {
+		t.Errorf("FeaturedImage = %q", post.FeaturedImage)
+	}
+	if len(parsed.Attachments) != 1 {
+		t.Fatalf("expected 1 attachment, got %d", len(parsed.Attachments))
+	}
+	a := parsed.Attachments[0]
+	if a.ID != 5 || a.ParentID != 1 || a.AltText != "A photo" || a.RelativePath != "photo.jpg" || a.MIMEType != "image/jpeg" {
+		t.Errorf("unexpected attachment: %+v", a)
+	}
+}
+
+func TestCDATASection(t *testing.T) {
+	tests := map[string]string{
+		"":             "<![CDATA[]]>",
+		"a]]>b":        "<![CDATA[a]]]]><![CDATA[>b]]>",
+		"bell\x07ring": "<![CDATA[bellring]]>",
+		"bad\xffbyte":  "<![CDATA[bad�byte]]>",
+	}
+	for in, want := range tests {
+		if got := cdataSection(in); got != want {
+			t.Errorf("cdataSection(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package wxr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_HTMLEntities(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Caf&eacute; &amp; Bar</title>
+	<item>
+		<title>Wait&hellip; &ldquo;What&rdquo;&nbsp;now &bogus; &#8217;</title>
+		<content:encoded><![CDATA[<p>Keep &eacute; here</p>]]></content:encoded>
+		<category domain="category" nicename="news-events">News &amp;amp; Events</category>
+		<category domain="post_tag" nicename="fa&ccedil;ade">Fa&ccedil;ade</category>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+		<wp:postmeta>
+			<wp:meta_key>subtitle</wp:meta_key>
+			<wp:meta_value>&frac12; price&mdash;today</wp:meta_value>
+		</wp:postmeta>
+	</item>
+</channel>
+</rss>`
+
+	posts, err := NewParser().WithPlainText(true).Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+	post := posts[0]
+
+	if want := "Wait… “What”\u00a0now &bogus; ’"; post.TitleRendered != want {
+		t.Errorf("TitleRendered = %q, want %q", post.TitleRendered, want)
+	}
+	if want := "<p>Keep &eacute; here</p>"; post.ContentRendered != want {
+		t.Errorf("ContentRendered = %q, want CDATA unchanged", post.ContentRendered)
+	}
+	if want := "½ price—today"; post.Meta["subtitle"] != want {
+		t.Errorf("Meta[subtitle] = %q, want %q", post.Meta["subtitle"], want)
+	}
+	if len(post.Tags) != 1 || post.Tags[0] != "Façade" {
+		t.Errorf("Tags = %v, want [Façade]", post.Tags)
+	}
+	if terms := post.Terms["post_tag"]; len(terms) != 1 || terms[0].Slug != "façade" {
+		t.Errorf("post_tag terms = %+v, want slug façade", terms)
+	}
+
+	// WordPress escapes term names before exporting them
+	if len(post.Categories) != 1 || post.Categories[0] != "News &amp; Events" {
+		t.Errorf("Categories = %v, want [News &amp; Events]", post.Categories)
+	}
+	if len(post.CategoriesPlain) != 1 || post.CategoriesPlain[0] != "News & Events" {
+		t.Errorf("CategoriesPlain = %v, want [News & Events]", post.CategoriesPlain)
+	}
+	if want := "Wait… “What”\u00a0now &bogus; ’"; post.TitlePlain != want {
+		t.Errorf("TitlePlain = %q, want %q", post.TitlePlain, want)
+	}
+
+	posts, err = Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if posts[0].TitlePlain != "" || posts[0].CategoriesPlain != nil {
+		t.Errorf("plain text variants set without WithPlainText: %q, %v", posts[0].TitlePlain, posts[0].CategoriesPlain)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"News &amp; Events", "News & Events"},
+		{"<em>Hello</em>\n  <strong>world</strong>", "Hello world"},
+		{"Don&#8217;t &hellip; stop", "Don’t … stop"},
+		{"a &lt; b", "a < b"},
+		{"  Plain  ", "Plain"},
+		{"<script>ȺȺȺȺȺȺȺȺȺȺ</script>Title", "ȺȺȺȺȺȺȺȺȺȺTitle"},
+		{"<textarea>ȺȺȺ &amp; ȺȺȺ</TEXTAREA> after", "ȺȺȺ & ȺȺȺ after"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := PlainText(tt.input); got != tt.want {
+			t.Errorf("PlainText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParser_WithPlainText_RawTextElements(t *testing.T) {
+	xml := legacyDocument("UTF-8", "&lt;textarea&gt;ȺȺȺȺȺȺȺȺȺȺ&lt;/textarea&gt; Title", "")
+	posts, err := NewParser().WithPlainText(true).Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if want := "ȺȺȺȺȺȺȺȺȺȺ Title"; len(posts) != 1 || posts[0].TitlePlain != want {
+		t.Errorf("posts = %+v, want TitlePlain %q", posts, want)
+	}
+}
@@ -0,0 +1,91 @@
+package wxr
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotWXR is returned, wrapped, when the root element of a document is not <rss>.
+var ErrNotWXR = errors.New("wxr: not a WXR document")
+
+// ErrUnsupportedCharset is returned, wrapped, when a document declares an
+// encoding that neither NewCharsetReader nor the parser's CharsetReader supports.
+var ErrUnsupportedCharset = errors.New("wxr: unsupported charset")
+
+// SyntaxError reports malformed XML, with the position in the input where the
+// decoder stopped.
+type SyntaxError struct {
+	// Line and Column are the 1-based line and byte column of the error.
+	Line   int
+	Column int
+
+	// Offset is the byte offset of the error from the start of the input.
+	Offset int64
+
+	// Msg describes the problem, such as "unexpected EOF".
+	Msg string
+
+	// Err is the underlying error, usually an *xml.SyntaxError.
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("wxr: failed to parse WXR XML: line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// ItemError reports an <item> that could not be decoded even though the document
+// around it is well-formed, such as a wp:post_id that is not a number. Streams
+// and All skip the item and continue with the next one; Parse fails.
+type ItemError struct {
+	// Index is the 1-based position of the item among the items of the document.
+	Index int
+
+	// PostID is the wp:post_id of the item, or 0 if it was not read.
+	PostID int
+
+	// Field is the element that could not be decoded, such as "wp:post_id",
+	// or "" when it is not known.
+	Field string
+
+	// Line and Column are the 1-based position of Field in the input, or 0.
+	Line   int
+	Column int
+
+	// Err is the underlying decoding error.
+	Err error
+}
+
+func (e *ItemError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "wxr: item %d", e.Index)
+	if e.PostID != 0 {
+		fmt.Fprintf(&b, " (post %d)", e.PostID)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, ", %s", e.Field)
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, " at line %d, column %d", e.Line, e.Column)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// qualifiedName returns name with the prefix WXR uses for its namespace, such as
+// "wp:post_id", or the bare local name for other namespaces.
+func qualifiedName(name xml.Name) string {
+	if prefix, ok := namespacePrefixes[name.Space]; ok && name.Space != "" {
+		return prefix + ":" + name.Local
+	}
+	return name.Local
+}
@@ -0,0 +1,112 @@
+package wxr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParse_SyntaxError(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title>Broken</title
+		<wp:post_id>1</wp:post_id>
+	</item>
+</channel>
+</rss>`
+
+	_, err := Parse(strings.NewReader(xml))
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *SyntaxError, got %T: %v", err, err)
+	}
+	if syntaxErr.Line != 6 || syntaxErr.Column != 4 {
+		t.Errorf("position = line %d, column %d, want line 6, column 4", syntaxErr.Line, syntaxErr.Column)
+	}
+	if want := int64(strings.Index(xml, "<wp:post_id>") + 1); syntaxErr.Offset != want {
+		t.Errorf("Offset = %d, want %d", syntaxErr.Offset, want)
+	}
+	if !strings.Contains(err.Error(), "line 6, column 4") {
+		t.Errorf("Error() = %q, want the position", err.Error())
+	}
+}
+
+func TestParse_UnexpectedEOF(t *testing.T) {
+	for _, input := range []string{"", `<?xml version="1.0"?>`, `<rss><channel><item><title>Cut`} {
+		_, err := Parse(strings.NewReader(input))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q): expected *SyntaxError, got %T: %v", input, err, err)
+		}
+		if input == "" && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Parse(%q): expected io.ErrUnexpectedEOF, got %v", input, err)
+		}
+	}
+}
+
+func TestParse_ErrNotWXR(t *testing.T) {
+	_, err := Parse(strings.NewReader(`<?xml version="1.0"?><feed><entry/></feed>`))
+	if !errors.Is(err, ErrNotWXR) {
+		t.Fatalf("expected ErrNotWXR, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"feed"`) {
+		t.Errorf("Error() = %q, want the root element name", err.Error())
+	}
+}
+
+func TestItemError(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title>Fine</title>
+		<wp:post_id>1</wp:post_id>
+	</item>
+	<item>
+		<title>Bad ID</title>
+		<wp:post_id>abc</wp:post_id>
+	</item>
+	<item>
+		<title>Bad comment</title>
+		<wp:post_id>3</wp:post_id>
+		<wp:comment>
+			<wp:comment_id>x</wp:comment_id>
+		</wp:comment>
+	</item>
+</channel>
+</rss>`
+
+	_, err := Parse(strings.NewReader(xml))
+	var itemErr *ItemError
+	if !errors.As(err, &itemErr) {
+		t.Fatalf("expected *ItemError from Parse, got %T: %v", err, err)
+	}
+	if itemErr.Index != 2 || itemErr.PostID != 0 || itemErr.Field != "wp:post_id" {
+		t.Errorf("ItemError = %+v, want item 2, field wp:post_id", itemErr)
+	}
+	if itemErr.Line != 10 || itemErr.Column != 3 {
+		t.Errorf("position = line %d, column %d, want line 10, column 3", itemErr.Line, itemErr.Column)
+	}
+
+	var errs []*ItemError
+	for _, err := range All(context.Background(), strings.NewReader(xml)) {
+		if errors.As(err, &itemErr) {
+			errs = append(errs, itemErr)
+		} else if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 item errors, got %v", errs)
+	}
+	if e := errs[1]; e.Index != 3 || e.PostID != 3 || e.Field != "wp:comment_id" || e.Line != 16 {
+		t.Errorf("ItemError = %+v, want item 3, post 3, field wp:comment_id on line 16", e)
+	}
+	if want := "wxr: item 3 (post 3), wp:comment_id at line 16, column 4: "; !strings.HasPrefix(errs[1].Error(), want) {
+		t.Errorf("Error() = %q, want prefix %q", errs[1].Error(), want)
+	}
+}
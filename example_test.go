@@ -1,6 +1,7 @@
 package wxr_test
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -87,3 +88,84 @@ func ExampleParser_Parse() {
 	fmt.Printf("Parsed %d posts\n", len(posts))
 	// Output: Parsed 1 posts
 }
+
+func ExampleParser_All() {
+	wxrXML := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Example Site</title>
+	<item>
+		<title><![CDATA[First Post]]></title>
+		<content:encoded><![CDATA[<p>One</p>]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Second Post]]></title>
+		<content:encoded><![CDATA[<p>Two</p>]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+	parser := wxr.NewParser()
+	for post, err := range parser.All(context.Background(), strings.NewReader(wxrXML)) {
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		fmt.Println(post.TitleRendered)
+	}
+	// Output:
+	// First Post
+	// Second Post
+}
+
+// draftFilter selects draft posts. Any type with a ShouldInclude(wxr.Item) bool
+// method can be used as a filter.
+type draftFilter struct{}
+
+func (draftFilter) ShouldInclude(item wxr.Item) bool {
+	return item.PostType() == "post" && item.Status() == "draft"
+}
+
+func ExampleParser_WithFilter() {
+	wxrXML := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Example Site</title>
+	<item>
+		<title><![CDATA[Published Post]]></title>
+		<content:encoded><![CDATA[<p>Live</p>]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Work in Progress]]></title>
+		<content:encoded><![CDATA[<p>Soon</p>]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[draft]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+	parser := wxr.NewParser().WithFilter(draftFilter{})
+	posts, err := parser.Parse(strings.NewReader(wxrXML))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, post := range posts {
+		fmt.Printf("%s (%s)\n", post.TitleRendered, post.Status)
+	}
+	// Output: Work in Progress (draft)
+}
@@ -6,16 +6,23 @@ import (
 )
 
 // AuthorExtractor handles author name resolution from WXR items.
-type AuthorExtractor struct{}
+type AuthorExtractor struct {
+	authors map[string]Author
+}
 
 // Extract extracts the author name from an item.
-// It prefers explicit meta fields (redator, autor, author_name), then falls back to dc:creator.
+// It prefers explicit meta fields (redator, autor, author_name), then the name of
+// the channel-level author whose login matches dc:creator, and finally dc:creator itself.
 func (e *AuthorExtractor) Extract(item *item) string {
 	author := getMetaValue(item.PostMeta, "redator", "autor", "author_name")
-	if author == "" {
-		author = item.DCCreator
+	if author != "" {
+		return strings.TrimSpace(author)
+	}
+	login := strings.TrimSpace(item.DCCreator)
+	if a, ok := e.authors[login]; ok {
+		return a.Name()
 	}
-	return strings.TrimSpace(author)
+	return login
 }
 
 // ExcerptExtractor handles excerpt extraction from WXR items.
@@ -65,6 +72,23 @@ func (e *FeaturedImageExtractor) Extract(item *item) string {
 	return ""
 }
 
+// pendingThumbnail reports the attachment ID the item's featured image is waiting for:
+// the item has a _thumbnail_id that is not in index yet and no custom meta field
+// that would take precedence over it.
+func (e *FeaturedImageExtractor) pendingThumbnail(item *item, index *AttachmentIndex) (int, bool) {
+	if getMetaValue(item.PostMeta, "banner_da_materia", "banner_old", "link_do_banner") != "" {
+		return 0, false
+	}
+	thumbID, err := strconv.Atoi(getMetaValue(item.PostMeta, "_thumbnail_id"))
+	if err != nil {
+		return 0, false
+	}
+	if _, ok := index.URLsByID[thumbID]; ok {
+		return 0, false
+	}
+	return thumbID, true
+}
+
 // DateExtractor handles date extraction and normalization from WXR items.
 type DateExtractor struct{}
 
@@ -113,13 +137,5 @@ type MetaExtractor struct{}
 
 // Extract extracts all meta fields from an item as a map.
 func (e *MetaExtractor) Extract(item *item) map[string]string {
-	meta := make(map[string]string)
-	for _, m := range item.PostMeta {
-		key := strings.TrimSpace(m.Key)
-		value := cleanMetaValue(m.Value)
-		if key != "" && value != "" {
-			meta[key] = value
-		}
-	}
-	return meta
+	return metaMap(item.PostMeta)
 }
@@ -1,9 +1,15 @@
 package wxr
 
+import (
+	"strings"
+	"time"
+)
+
 // Filter defines the interface for filtering WXR items.
 // Implementations determine whether an item should be included in the parsed results.
+// The parser consults its filter for every item before transforming it into a Post.
 type Filter interface {
-	ShouldInclude(item *item) bool
+	ShouldInclude(item Item) bool
 }
 
 // DefaultFilter implements the default filtering strategy.
@@ -14,11 +20,12 @@ type DefaultFilter struct {
 }
 
 // ShouldInclude returns true if the item matches the filter criteria.
-func (f *DefaultFilter) ShouldInclude(item *item) bool {
-	if f.PostType != "" && item.PostType != f.PostType {
+// An empty PostType or Status matches any value.
+func (f *DefaultFilter) ShouldInclude(item Item) bool {
+	if f.PostType != "" && item.PostType() != f.PostType {
 		return false
 	}
-	if f.Status != "" && item.Status != f.Status {
+	if f.Status != "" && item.Status() != f.Status {
 		return false
 	}
 	return true
@@ -31,3 +38,199 @@ func NewDefaultFilter() *DefaultFilter {
 		Status:   "publish",
 	}
 }
+
+// internalPostTypes are the post types WordPress uses for media, revisions and
+// site structure rather than content.
+var internalPostTypes = []string{
+	"attachment",
+	"revision",
+	"nav_menu_item",
+	"custom_css",
+	"customize_changeset",
+	"oembed_cache",
+	"user_request",
+	"wp_block",
+	"wp_template",
+	"wp_template_part",
+	"wp_global_styles",
+	"wp_navigation",
+	"wp_font_family",
+	"wp_font_face",
+}
+
+// NewContentFilter creates a filter for migrating a whole site: it includes published
+// items of every content post type (posts, pages and custom post types such as
+// "product" or "event") and excludes attachments and WordPress-internal types like
+// revisions and navigation menu items.
+func NewContentFilter() Filter {
+	return And(ByStatus("publish"), Not(ByPostType(internalPostTypes...)))
+}
+
+// FilterFunc adapts an ordinary function to the Filter interface.
+type FilterFunc func(item Item) bool
+
+// ShouldInclude calls f(item).
+func (f FilterFunc) ShouldInclude(item Item) bool {
+	return f(item)
+}
+
+// And returns a filter that includes an item only if every given filter includes it.
+// With no filters it includes every item.
+func And(filters ...Filter) Filter {
+	return FilterFunc(func(item Item) bool {
+		for _, f := range filters {
+			if !f.ShouldInclude(item) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or returns a filter that includes an item if any of the given filters includes it.
+// With no filters it includes no items.
+func Or(filters ...Filter) Filter {
+	return FilterFunc(func(item Item) bool {
+		for _, f := range filters {
+			if f.ShouldInclude(item) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not returns a filter that includes exactly the items the given filter excludes.
+func Not(filter Filter) Filter {
+	return FilterFunc(func(item Item) bool {
+		return !filter.ShouldInclude(item)
+	})
+}
+
+// ByPostType returns a filter that includes items of any of the given post types.
+func ByPostType(postTypes ...string) Filter {
+	set := stringSet(postTypes)
+	return FilterFunc(func(item Item) bool {
+		return set[item.PostType()]
+	})
+}
+
+// ByStatus returns a filter that includes items with any of the given statuses.
+func ByStatus(statuses ...string) Filter {
+	set := stringSet(statuses)
+	return FilterFunc(func(item Item) bool {
+		return set[item.Status()]
+	})
+}
+
+// ByID returns a filter that includes items with any of the given post IDs.
+func ByID(ids ...int) Filter {
+	set := intSet(ids)
+	return FilterFunc(func(item Item) bool {
+		return set[item.ID()]
+	})
+}
+
+// ByParent returns a filter that includes items whose parent is any of the given post IDs.
+// Use ByParent(0) to select top-level items.
+func ByParent(ids ...int) Filter {
+	set := intSet(ids)
+	return FilterFunc(func(item Item) bool {
+		return set[item.ParentID()]
+	})
+}
+
+// ByAuthor returns a filter that includes items whose author login (dc:creator)
+// matches any of the given logins, ignoring case.
+func ByAuthor(logins ...string) Filter {
+	return FilterFunc(func(item Item) bool {
+		creator := item.Creator()
+		for _, login := range logins {
+			if strings.EqualFold(creator, login) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ByTerm returns a filter that includes items assigned to a term of the given
+// taxonomy whose name or slug matches any of the given values, ignoring case.
+func ByTerm(taxonomy string, values ...string) Filter {
+	return FilterFunc(func(item Item) bool {
+		for _, term := range item.Terms() {
+			if term.Taxonomy != taxonomy {
+				continue
+			}
+			for _, value := range values {
+				if strings.EqualFold(term.Name, value) || strings.EqualFold(term.Slug, value) {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// ByCategory returns a filter that includes items in any of the given categories,
+// matched by name or slug.
+func ByCategory(values ...string) Filter {
+	return ByTerm("category", values...)
+}
+
+// ByTag returns a filter that includes items with any of the given tags,
+// matched by name or slug.
+func ByTag(values ...string) Filter {
+	return ByTerm("post_tag", values...)
+}
+
+// ByDateRange returns a filter that includes items whose post_date_gmt falls in
+// the half-open range [from, to). A zero from or to leaves that end unbounded.
+// Items without a valid post_date_gmt, such as unscheduled drafts, are excluded.
+func ByDateRange(from, to time.Time) Filter {
+	return FilterFunc(func(item Item) bool {
+		date, ok := parseWXRDate(item.PostDateGMT())
+		if !ok {
+			return false
+		}
+		if !from.IsZero() && date.Before(from) {
+			return false
+		}
+		if !to.IsZero() && !date.Before(to) {
+			return false
+		}
+		return true
+	})
+}
+
+// HasMeta returns a filter that includes items with a post meta entry for key.
+func HasMeta(key string) Filter {
+	return FilterFunc(func(item Item) bool {
+		_, ok := item.Meta(key)
+		return ok
+	})
+}
+
+// MetaEquals returns a filter that includes items whose post meta value for key equals value.
+func MetaEquals(key, value string) Filter {
+	return FilterFunc(func(item Item) bool {
+		v, ok := item.Meta(key)
+		return ok && v == value
+	})
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func intSet(values []int) map[int]bool {
+	set := make(map[int]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,201 @@
+package wxr
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const filterXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Test Site</title>
+	<item>
+		<title><![CDATA[About]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Draft Post]]></title>
+		<dc:creator><![CDATA[msilva]]></dc:creator>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_date_gmt><![CDATA[2024-03-10 12:00:00]]></wp:post_date_gmt>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[draft]]></wp:status>
+		<category domain="category" nicename="news"><![CDATA[News]]></category>
+		<category nicename="world"><![CDATA[World]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[featured]]></wp:meta_key>
+			<wp:meta_value><![CDATA[yes]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title><![CDATA[Published Post]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>3</wp:post_id>
+		<wp:post_parent>1</wp:post_parent>
+		<wp:post_date_gmt><![CDATA[2025-01-05 08:30:00]]></wp:post_date_gmt>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+	</item>
+</channel>
+</rss>`
+
+// typeFilter is a custom Filter selecting items by post type only.
+type typeFilter struct {
+	types []string
+}
+
+func (f typeFilter) ShouldInclude(item Item) bool {
+	for _, t := range f.types {
+		if item.PostType() == t {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIDs(t *testing.T, filter Filter) []int {
+	t.Helper()
+	posts, err := NewParser().WithFilter(filter).Parse(strings.NewReader(filterXML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+func TestParser_WithFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{name: "default", filter: nil, want: []int{3}},
+		{name: "drafts", filter: &DefaultFilter{PostType: "post", Status: "draft"}, want: []int{2}},
+		{name: "any post status", filter: &DefaultFilter{PostType: "post"}, want: []int{2, 3}},
+		{name: "published pages", filter: &DefaultFilter{PostType: "page", Status: "publish"}, want: []int{1}},
+		{name: "custom filter", filter: typeFilter{types: []string{"page", "post"}}, want: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseIDs(t, tt.filter); !slices.Equal(got, tt.want) {
+				t.Errorf("expected posts %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStream_WithFilter(t *testing.T) {
+	stream := NewParser().WithFilter(&DefaultFilter{Status: "draft"}).NewStream(strings.NewReader(filterXML))
+	posts := readStream(t, stream)
+	if len(posts) != 1 || posts[0].ID != 2 {
+		t.Fatalf("expected only draft post 2, got %+v", posts)
+	}
+	if posts[0].Status != "draft" {
+		t.Errorf("expected status 'draft', got %q", posts[0].Status)
+	}
+}
+
+func TestItem(t *testing.T) {
+	var seen Item
+	filter := FilterFunc(func(item Item) bool {
+		if item.ID() == 2 {
+			seen = item
+		}
+		return false
+	})
+	if _, err := NewParser().WithFilter(filter).Parse(strings.NewReader(filterXML)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if seen.Creator() != "msilva" {
+		t.Errorf("expected creator 'msilva', got %q", seen.Creator())
+	}
+	if seen.PostDateGMT() != "2024-03-10 12:00:00" {
+		t.Errorf("unexpected post_date_gmt %q", seen.PostDateGMT())
+	}
+	if value, ok := seen.Meta("featured"); !ok || value != "yes" {
+		t.Errorf("expected meta featured=yes, got %q, %v", value, ok)
+	}
+	if _, ok := seen.Meta("missing"); ok {
+		t.Error("expected missing meta key to be reported absent")
+	}
+
+	terms := seen.Terms()
+	want := []Term{
+		{Taxonomy: "category", Name: "News", Slug: "news"},
+		{Taxonomy: "category", Name: "World", Slug: "world"},
+		{Taxonomy: "post_tag", Name: "Go", Slug: "go"},
+	}
+	if len(terms) != len(want) {
+		t.Fatalf("expected %d terms, got %+v", len(want), terms)
+	}
+	for i := range want {
+		if terms[i].Taxonomy != want[i].Taxonomy || terms[i].Name != want[i].Name || terms[i].Slug != want[i].Slug {
+			t.Errorf("terms[%d] = %+v, want %+v", i, terms[i], want[i])
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{name: "post type", filter: ByPostType("page"), want: []int{1}},
+		{name: "status", filter: ByStatus("publish", "draft"), want: []int{1, 2, 3}},
+		{name: "id", filter: ByID(1, 3), want: []int{1, 3}},
+		{name: "parent", filter: ByParent(1), want: []int{3}},
+		{name: "top level", filter: ByParent(0), want: []int{1, 2}},
+		{name: "author", filter: ByAuthor("MSilva"), want: []int{2}},
+		{name: "category by name", filter: ByCategory("news"), want: []int{2}},
+		{name: "category without domain", filter: ByCategory("world"), want: []int{2}},
+		{name: "tag by slug", filter: ByTag("go"), want: []int{2, 3}},
+		{name: "term", filter: ByTerm("post_tag", "Go"), want: []int{2, 3}},
+		{name: "has meta", filter: HasMeta("featured"), want: []int{2}},
+		{name: "meta equals", filter: MetaEquals("featured", "no"), want: nil},
+		{
+			name:   "date range",
+			filter: ByDateRange(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}),
+			want:   []int{3},
+		},
+		{
+			name:   "date range end is exclusive",
+			filter: ByDateRange(time.Time{}, time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)),
+			want:   nil,
+		},
+		{name: "and", filter: And(ByPostType("post"), ByTag("go")), want: []int{2, 3}},
+		{name: "or", filter: Or(ByPostType("page"), ByStatus("draft")), want: []int{1, 2}},
+		{name: "not", filter: Not(ByPostType("post")), want: []int{1}},
+		{name: "empty and", filter: And(), want: []int{1, 2, 3}},
+		{name: "empty or", filter: Or(), want: nil},
+		{
+			name:   "nested",
+			filter: And(ByPostType("post"), Or(ByCategory("news"), Not(HasMeta("featured")))),
+			want:   []int{2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseIDs(t, tt.filter); !slices.Equal(got, tt.want) {
+				t.Errorf("expected posts %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
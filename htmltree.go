@@ -0,0 +1,371 @@
+package wxr
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlNodeKind is the kind of an htmlNode.
+type htmlNodeKind int
+
+const (
+	htmlDocument htmlNodeKind = iota
+	htmlElement
+	htmlText
+	htmlComment
+)
+
+// htmlNode is a node of the lenient HTML tree used to convert post content.
+type htmlNode struct {
+	kind     htmlNodeKind
+	tag      string // lowercase element name
+	attrs    []htmlAttr
+	text     string // decoded text, or comment body
+	children []*htmlNode
+	parent   *htmlNode
+}
+
+// htmlAttr is an element attribute with its decoded value.
+type htmlAttr struct {
+	name  string
+	value string
+}
+
+// attr returns the value of the named attribute.
+func (n *htmlNode) attr(name string) string {
+	for _, a := range n.attrs {
+		if a.name == name {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether the element has the named attribute.
+func (n *htmlNode) hasAttr(name string) bool {
+	for _, a := range n.attrs {
+		if a.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *htmlNode) appendChild(child *htmlNode) {
+	child.parent = n
+	n.children = append(n.children, child)
+}
+
+// htmlVoidElements never have content or an end tag.
+var htmlVoidElements = tagSet("area", "base", "br", "col", "embed", "hr", "img", "input",
+	"link", "meta", "param", "source", "track", "wbr")
+
+// htmlRawTextElements contain text up to their end tag, without markup.
+var htmlRawTextElements = tagSet("script", "style", "textarea", "title", "xmp")
+
+// htmlClosesParagraph lists the elements whose start tag closes an open <p>.
+var htmlClosesParagraph = tagSet("address", "article", "aside", "blockquote", "details",
+	"div", "dl", "fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4",
+	"h5", "h6", "header", "hr", "main", "nav", "ol", "p", "pre", "section", "table", "ul")
+
+// parseHTML parses an HTML fragment into a tree, recovering from unclosed and
+// misnested elements the way browsers do for the common cases found in posts.
+func parseHTML(s string) *htmlNode {
+	root := &htmlNode{kind: htmlDocument}
+	stack := []*htmlNode{root}
+	current := func() *htmlNode { return stack[len(stack)-1] }
+
+	// closeTo pops the stack up to and including the innermost element named tag,
+	// without crossing any of the boundary elements. It reports whether it did.
+	closeTo := func(tag string, boundary map[string]bool) bool {
+		for i := len(stack) - 1; i > 0; i-- {
+			if stack[i].tag == tag {
+				stack = stack[:i]
+				return true
+			}
+			if boundary[stack[i].tag] {
+				return false
+			}
+		}
+		return false
+	}
+	listBoundary := tagSet("ul", "ol", "table", "blockquote")
+	tableBoundary := tagSet("table")
+	rowBoundary := tagSet("tr", "table")
+	dlBoundary := tagSet("dl", "table")
+	paragraphBoundary := tagSet("blockquote", "li", "td", "th", "figure", "div", "section", "article", "table")
+
+	for i := 0; i < len(s); {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			current().appendChild(&htmlNode{kind: htmlText, text: html.UnescapeString(s[i:])})
+			break
+		}
+		if lt > 0 {
+			current().appendChild(&htmlNode{kind: htmlText, text: html.UnescapeString(s[i : i+lt])})
+			i += lt
+		}
+
+		switch {
+		case strings.HasPrefix(s[i:], "<!--"):
+			end := strings.Index(s[i+4:], "-->")
+			if end < 0 {
+				current().appendChild(&htmlNode{kind: htmlComment, text: s[i+4:]})
+				i = len(s)
+				continue
+			}
+			current().appendChild(&htmlNode{kind: htmlComment, text: s[i+4 : i+4+end]})
+			i += 4 + end + 3
+
+		case strings.HasPrefix(s[i:], "<!") || strings.HasPrefix(s[i:], "<?"):
+			// Doctype or processing instruction
+			end := strings.IndexByte(s[i:], '>')
+			if end < 0 {
+				i = len(s)
+			} else {
+				i += end + 1
+			}
+
+		case strings.HasPrefix(s[i:], "</"):
+			name, n := htmlTagName(s, i+2)
+			if name == "" {
+				current().appendChild(&htmlNode{kind: htmlText, text: "</"})
+				i += 2
+				continue
+			}
+			end := strings.IndexByte(s[i+n:], '>')
+			if end < 0 {
+				i = len(s)
+			} else {
+				i += n + end + 1
+			}
+			closeTo(name, nil)
+
+		default:
+			name, n := htmlTagName(s, i+1)
+			if name == "" {
+				current().appendChild(&htmlNode{kind: htmlText, text: "<"})
+				i++
+				continue
+			}
+			attrs, selfClosing, end := htmlTagAttrs(s, i+1+n)
+			i = end
+
+			// Implied end tags
+			if htmlClosesParagraph[name] {
+				closeTo("p", paragraphBoundary)
+			}
+			switch name {
+			case "li":
+				closeTo("li", listBoundary)
+			case "dt", "dd":
+				if !closeTo("dt", dlBoundary) {
+					closeTo("dd", dlBoundary)
+				}
+			case "tr":
+				closeTo("tr", tableBoundary)
+			case "td", "th":
+				if !closeTo("td", rowBoundary) {
+					closeTo("th", rowBoundary)
+				}
+			}
+
+			el := &htmlNode{kind: htmlElement, tag: name, attrs: attrs}
+			current().appendChild(el)
+			if htmlVoidElements[name] || selfClosing {
+				continue
+			}
+			if htmlRawTextElements[name] {
+				closing := indexClosingTag(s[i:], name)
+				if closing < 0 {
+					closing = len(s) - i
+				}
+				text := s[i : i+closing]
+				if name == "textarea" || name == "title" {
+					text = html.UnescapeString(text)
+				}
+				el.appendChild(&htmlNode{kind: htmlText, text: text})
+				i += closing
+				if end := strings.IndexByte(s[i:], '>'); end >= 0 {
+					i += end + 1
+				}
+				continue
+			}
+			stack = append(stack, el)
+		}
+	}
+	return root
+}
+
+// indexClosingTag returns the index of the first "</name" in s, comparing the
+// name case-insensitively, or -1. Only ASCII letters are folded, so that indexes
+// into s stay valid whatever the text around the tag.
+func indexClosingTag(s, name string) int {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "</")
+		if j < 0 {
+			return -1
+		}
+		i += j
+		rest := s[i+2:]
+		if len(rest) >= len(name) && equalFoldASCII(rest[:len(name)], name) {
+			return i
+		}
+		i += 2
+	}
+}
+
+// equalFoldASCII reports whether a and b are equal, ignoring the case of ASCII letters.
+func equalFoldASCII(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		x, y := a[i], b[i]
+		if 'A' <= x && x <= 'Z' {
+			x += 'a' - 'A'
+		}
+		if 'A' <= y && y <= 'Z' {
+			y += 'a' - 'A'
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// htmlTagName reads a tag name at i and returns it in lowercase with its length.
+func htmlTagName(s string, i int) (string, int) {
+	if i >= len(s) || !isASCIILetter(s[i]) {
+		return "", 0
+	}
+	n := 0
+	for i+n < len(s) && !isHTMLSpace(s[i+n]) && s[i+n] != '/' && s[i+n] != '>' {
+		n++
+	}
+	return strings.ToLower(s[i : i+n]), n
+}
+
+// htmlTagAttrs reads the attributes of a start tag from i up to its ">",
+// returning them with whether the tag ends with "/>" and the index after it.
+func htmlTagAttrs(s string, i int) ([]htmlAttr, bool, int) {
+	var attrs []htmlAttr
+	for {
+		for i < len(s) && (isHTMLSpace(s[i]) || s[i] == '/') {
+			if s[i] == '/' && i+1 < len(s) && s[i+1] == '>' {
+				return attrs, true, i + 2
+			}
+			i++
+		}
+		if i >= len(s) {
+			return attrs, false, i
+		}
+		if s[i] == '>' {
+			return attrs, false, i + 1
+		}
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && (s[i] != '/' || i == start) {
+			i++
+		}
+		attr := htmlAttr{name: strings.ToLower(s[start:i])}
+		j := i
+		for j < len(s) && isHTMLSpace(s[j]) {
+			j++
+		}
+		if j < len(s) && s[j] == '=' {
+			j++
+			for j < len(s) && isHTMLSpace(s[j]) {
+				j++
+			}
+			if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+				end := strings.IndexByte(s[j+1:], s[j])
+				if end < 0 {
+					end = len(s) - j - 1
+				}
+				attr.value = html.UnescapeString(s[j+1 : j+1+end])
+				i = min(j+1+end+1, len(s))
+			} else {
+				k := j
+				for k < len(s) && !isHTMLSpace(s[k]) && s[k] != '>' {
+					k++
+				}
+				attr.value = html.UnescapeString(s[j:k])
+				i = k
+			}
+		}
+		attrs = append(attrs, attr)
+	}
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// renderHTML writes a node back as HTML.
+func renderHTML(b *strings.Builder, n *htmlNode) {
+	switch n.kind {
+	case htmlText:
+		if n.parent != nil && htmlRawTextElements[n.parent.tag] {
+			b.WriteString(n.text)
+		} else {
+			b.WriteString(html.EscapeString(n.text))
+		}
+	case htmlComment:
+		b.WriteString("<!--" + n.text + "-->")
+	case htmlDocument:
+		for _, c := range n.children {
+			renderHTML(b, c)
+		}
+	case htmlElement:
+		b.WriteString(htmlStartTag(n))
+		if htmlVoidElements[n.tag] {
+			return
+		}
+		for _, c := range n.children {
+			renderHTML(b, c)
+		}
+		b.WriteString("</" + n.tag + ">")
+	}
+}
+
+// htmlStartTag returns the start tag of an element.
+func htmlStartTag(n *htmlNode) string {
+	var b strings.Builder
+	b.WriteString("<" + n.tag)
+	for _, a := range n.attrs {
+		b.WriteString(" " + a.name + `="` + html.EscapeString(a.value) + `"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// textContent returns the text of a node and its descendants, with <br> as a newline.
+func textContent(n *htmlNode) string {
+	var b strings.Builder
+	var walk func(*htmlNode)
+	walk = func(n *htmlNode) {
+		switch {
+		case n.kind == htmlText:
+			b.WriteString(n.text)
+		case n.kind == htmlElement && n.tag == "br":
+			b.WriteByte('\n')
+		default:
+			for _, c := range n.children {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// tagSet builds a set of element names.
+func tagSet(tags ...string) map[string]bool {
+	return stringSet(tags)
+}
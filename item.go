@@ -0,0 +1,93 @@
+package wxr
+
+import "strings"
+
+// Item is a read-only view of a raw WXR <item>.
+// Filters receive an Item before it is transformed into a Post, so they can select
+// items by any of the fields WordPress exports.
+type Item struct {
+	item *item
+}
+
+// ID returns the WordPress post ID (wp:post_id).
+func (i Item) ID() int {
+	return i.item.PostID
+}
+
+// ParentID returns the ID of the parent post (wp:post_parent), or 0 if there is none.
+func (i Item) ParentID() int {
+	return i.item.PostParent
+}
+
+// MenuOrder returns the position of the item among its siblings (wp:menu_order).
+func (i Item) MenuOrder() int {
+	return i.item.MenuOrder
+}
+
+// PostType returns the post type (wp:post_type), such as "post", "page" or "attachment".
+func (i Item) PostType() string {
+	return i.item.PostType
+}
+
+// Status returns the post status (wp:status), such as "publish", "draft" or "inherit".
+func (i Item) Status() string {
+	return i.item.Status
+}
+
+// Title returns the raw item title.
+func (i Item) Title() string {
+	return i.item.Title
+}
+
+// Slug returns the post slug (wp:post_name).
+func (i Item) Slug() string {
+	return i.item.PostName
+}
+
+// Creator returns the login of the post author (dc:creator).
+func (i Item) Creator() string {
+	return strings.TrimSpace(i.item.DCCreator)
+}
+
+// PostDate returns the raw publication date in the site's time zone (wp:post_date).
+func (i Item) PostDate() string {
+	return i.item.PostDate
+}
+
+// PostDateGMT returns the raw publication date in UTC (wp:post_date_gmt).
+func (i Item) PostDateGMT() string {
+	return i.item.PostDateGMT
+}
+
+// Modified returns the raw modification date in the site's time zone (wp:post_modified).
+func (i Item) Modified() string {
+	return i.item.PostModified
+}
+
+// ModifiedGMT returns the raw modification date in UTC (wp:post_modified_gmt).
+func (i Item) ModifiedGMT() string {
+	return i.item.PostModifiedGMT
+}
+
+// Terms returns the taxonomy terms assigned to the item through its <category> elements.
+func (i Item) Terms() []Term {
+	return itemTerms(i.item)
+}
+
+// Meta returns the trimmed value of the first post meta entry with the given key,
+// and whether such an entry exists. Unlike Post.Meta, empty and "null" values are
+// reported as present.
+func (i Item) Meta(key string) (string, bool) {
+	for _, m := range i.item.PostMeta {
+		if strings.TrimSpace(m.Key) == key {
+			return strings.TrimSpace(m.Value), true
+		}
+	}
+	return "", false
+}
+
+// Extra returns the item elements the package does not map, such as
+// wp:comment_status or elements added by plugins.
+func (i Item) Extra() Nodes {
+	return i.item.Extra
+}
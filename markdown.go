@@ -0,0 +1,809 @@
+package wxr
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// UnknownHTMLMode controls how a MarkdownConverter writes HTML elements that have
+// no Markdown equivalent, such as <iframe>, <video> or <sup>.
+type UnknownHTMLMode int
+
+const (
+	// UnknownHTMLKeep writes the elements as HTML, which Markdown renderers pass through.
+	UnknownHTMLKeep UnknownHTMLMode = iota
+
+	// UnknownHTMLText replaces the elements with their converted content.
+	UnknownHTMLText
+
+	// UnknownHTMLDrop removes the elements and their content.
+	UnknownHTMLDrop
+)
+
+// MarkdownConverter converts post content to CommonMark, using GitHub Flavored
+// Markdown for tables and strikethrough.
+//
+// Content is converted the way WordPress renders it: block delimiters are
+// removed, with embeds turned into links and page breaks dropped, and shortcodes
+// are expanded before the HTML is converted.
+type MarkdownConverter struct {
+	autoP          bool
+	referenceLinks bool
+	unknownHTML    UnknownHTMLMode
+	shortcodes     *ShortcodeRegistry
+}
+
+// NewMarkdownConverter creates a converter that writes inline links, keeps
+// unknown HTML and expands the core shortcodes (see NewShortcodeRegistry).
+func NewMarkdownConverter() *MarkdownConverter {
+	return &MarkdownConverter{
+		unknownHTML: UnknownHTMLKeep,
+		shortcodes:  NewShortcodeRegistry(),
+	}
+}
+
+// WithAutoP runs AutoP on classic editor content before conversion, so that
+// paragraphs separated by blank lines are kept. Use it for content that was not
+// parsed with Parser.WithAutoP.
+// Returns the converter for method chaining.
+func (c *MarkdownConverter) WithAutoP(enabled bool) *MarkdownConverter {
+	c.autoP = enabled
+	return c
+}
+
+// WithReferenceLinks writes links and images as numbered references, such as
+// [text][1], with the link definitions at the end of the document.
+// Returns the converter for method chaining.
+func (c *MarkdownConverter) WithReferenceLinks(enabled bool) *MarkdownConverter {
+	c.referenceLinks = enabled
+	return c
+}
+
+// WithUnknownHTML sets how elements without a Markdown equivalent are written.
+// Returns the converter for method chaining.
+func (c *MarkdownConverter) WithUnknownHTML(mode UnknownHTMLMode) *MarkdownConverter {
+	c.unknownHTML = mode
+	return c
+}
+
+// WithShortcodes sets the registry used to expand shortcodes before conversion,
+// for example one with attachments so that galleries render their images.
+// A nil registry leaves shortcodes in the text.
+// Returns the converter for method chaining.
+func (c *MarkdownConverter) WithShortcodes(registry *ShortcodeRegistry) *MarkdownConverter {
+	c.shortcodes = registry
+	return c
+}
+
+// Convert converts post content to Markdown.
+func (c *MarkdownConverter) Convert(content string) string {
+	if c.autoP && !hasBlocks(content) {
+		content = AutoP(content, true)
+	}
+	content = blocksHTML(ParseBlocks(content))
+	if c.shortcodes != nil {
+		content = c.shortcodes.Expand(content)
+	}
+
+	w := &markdownWriter{converter: c, refs: make(map[string]int)}
+	out := strings.Join(w.blocks(parseHTML(content).children), "\n\n")
+	if len(w.defs) > 0 {
+		if out != "" {
+			out += "\n\n"
+		}
+		out += strings.Join(w.defs, "\n")
+	}
+	return out
+}
+
+// ToMarkdown converts post content to Markdown with the default converter.
+// See NewMarkdownConverter.
+func ToMarkdown(content string) string {
+	return NewMarkdownConverter().Convert(content)
+}
+
+// Markdown converts the post content to Markdown with the default converter.
+// See NewMarkdownConverter.
+func (p Post) Markdown() string {
+	return ToMarkdown(p.ContentRendered)
+}
+
+// blocksHTML renders blocks as HTML without their comment delimiters. Embeds
+// become links to the embedded URL, and the more and page break blocks are dropped.
+func blocksHTML(blocks []*Block) string {
+	var b strings.Builder
+	var write func(*Block)
+	write = func(block *Block) {
+		switch {
+		case block.Name == "core/more" || block.Name == "core/nextpage":
+			return
+		case block.Name == "core/embed" || strings.HasPrefix(block.Name, "core-embed/"):
+			if url, _ := block.Attrs["url"].(string); url != "" {
+				escaped := html.EscapeString(url)
+				b.WriteString(`<p><a href="` + escaped + `">` + escaped + `</a></p>`)
+				return
+			}
+		}
+		index := 0
+		for _, chunk := range block.InnerContent {
+			if chunk != nil {
+				b.WriteString(*chunk)
+			} else if index < len(block.InnerBlocks) {
+				write(block.InnerBlocks[index])
+				index++
+			}
+		}
+	}
+	for _, block := range blocks {
+		write(block)
+	}
+	return b.String()
+}
+
+// markdownBlockElements are the block elements with a Markdown syntax.
+var markdownBlockElements = tagSet("p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol",
+	"blockquote", "pre", "hr", "table", "figcaption", "dt", "dd")
+
+// markdownBlockContainers are block elements without Markdown syntax whose
+// content is converted in place.
+var markdownBlockContainers = tagSet("div", "section", "article", "header", "footer", "main",
+	"aside", "nav", "address", "center", "hgroup", "figure", "dl", "li", "thead", "tbody",
+	"tfoot", "tr", "td", "th", "caption")
+
+// markdownInlineContainers are inline elements without Markdown syntax whose
+// content is converted in place.
+var markdownInlineContainers = tagSet("span", "font", "small", "big", "abbr", "acronym",
+	"time", "label", "bdi", "bdo", "data", "picture", "nobr", "wbr")
+
+// markdownDroppedElements are removed with their content.
+var markdownDroppedElements = tagSet("script", "style", "noscript", "template", "head",
+	"meta", "link", "title")
+
+// htmlBlockElements are the block elements without Markdown syntax that are
+// written according to the UnknownHTMLMode.
+var htmlBlockElements = tagSet("iframe", "video", "audio", "object", "canvas", "svg", "math",
+	"form", "fieldset", "details", "dialog", "menu", "map")
+
+func isBlockElement(tag string) bool {
+	return markdownBlockElements[tag] || markdownBlockContainers[tag] || htmlBlockElements[tag]
+}
+
+// markdownWriter holds the state of a conversion.
+type markdownWriter struct {
+	converter *MarkdownConverter
+	refs      map[string]int // link definition key to reference number
+	defs      []string       // link definitions, in reference order
+}
+
+// blocks converts nodes to Markdown blocks. Inline content between block
+// elements is gathered into paragraphs.
+func (w *markdownWriter) blocks(nodes []*htmlNode) []string {
+	var out []string
+	var paragraph strings.Builder
+	flush := func() {
+		if text := formatInline(paragraph.String()); text != "" {
+			out = append(out, text)
+		}
+		paragraph.Reset()
+	}
+	for _, n := range nodes {
+		if n.kind == htmlElement && markdownDroppedElements[n.tag] {
+			continue
+		}
+		if n.kind == htmlElement && isBlockElement(n.tag) {
+			flush()
+			out = append(out, w.block(n)...)
+			continue
+		}
+		paragraph.WriteString(w.inline(n))
+	}
+	flush()
+	return out
+}
+
+// block converts a block element.
+func (w *markdownWriter) block(n *htmlNode) []string {
+	switch n.tag {
+	case "p", "dd":
+		return w.blocks(n.children)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := formatInline(strings.ReplaceAll(w.inlineChildren(n), "\n", " "))
+		if text == "" {
+			return nil
+		}
+		return []string{strings.Repeat("#", int(n.tag[1]-'0')) + " " + text}
+	case "dt", "figcaption":
+		delim := "**"
+		if n.tag == "figcaption" {
+			delim = "*"
+		}
+		if text := formatInline(w.inlineChildren(n)); text != "" {
+			return []string{emphasize(text, delim)}
+		}
+		return nil
+	case "hr":
+		return []string{"---"}
+	case "ul", "ol":
+		if list := w.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case "blockquote":
+		inner := strings.Join(w.blocks(n.children), "\n\n")
+		if inner == "" {
+			return nil
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			if line == "" {
+				lines[i] = ">"
+			} else {
+				lines[i] = "> " + line
+			}
+		}
+		return []string{strings.Join(lines, "\n")}
+	case "pre":
+		return []string{codeBlock(n)}
+	case "table":
+		return w.table(n)
+	}
+	if markdownBlockContainers[n.tag] {
+		return w.blocks(n.children)
+	}
+
+	switch w.converter.unknownHTML {
+	case UnknownHTMLKeep:
+		return []string{rawHTMLBlock(n)}
+	case UnknownHTMLText:
+		return w.blocks(n.children)
+	}
+	return nil
+}
+
+// inline converts a node in a paragraph. Line breaks are returned as newlines;
+// see formatInline.
+func (w *markdownWriter) inline(n *htmlNode) string {
+	switch n.kind {
+	case htmlText:
+		return escapeMarkdown(collapseSpace(n.text))
+	case htmlElement:
+	default:
+		return ""
+	}
+
+	switch n.tag {
+	case "br":
+		return "\n"
+	case "strong", "b":
+		return emphasize(w.inlineChildren(n), "**")
+	case "em", "i", "cite", "dfn", "var":
+		return emphasize(w.inlineChildren(n), "*")
+	case "del", "s", "strike":
+		return emphasize(w.inlineChildren(n), "~~")
+	case "code", "kbd", "samp", "tt":
+		return codeSpan(collapseSpace(textContent(n)))
+	case "q":
+		return `"` + w.inlineChildren(n) + `"`
+	case "a":
+		return w.link(n)
+	case "img":
+		return w.image(n)
+	}
+	switch {
+	case markdownDroppedElements[n.tag]:
+		return ""
+	case markdownInlineContainers[n.tag]:
+		return w.inlineChildren(n)
+	case markdownBlockElements[n.tag] || markdownBlockContainers[n.tag]:
+		// A block element inside inline content, such as a <div> in a link
+		return "\n" + w.inlineChildren(n) + "\n"
+	}
+
+	switch w.converter.unknownHTML {
+	case UnknownHTMLKeep:
+		// Markdown in inline HTML is still converted by renderers, so only the
+		// tags are written as HTML.
+		if htmlVoidElements[n.tag] {
+			return htmlStartTag(n)
+		}
+		return htmlStartTag(n) + w.inlineChildren(n) + "</" + n.tag + ">"
+	case UnknownHTMLText:
+		return w.inlineChildren(n)
+	}
+	return ""
+}
+
+func (w *markdownWriter) inlineChildren(n *htmlNode) string {
+	var b strings.Builder
+	for _, c := range n.children {
+		b.WriteString(w.inline(c))
+	}
+	return b.String()
+}
+
+// link converts an <a> element. Links whose text is their URL become autolinks.
+func (w *markdownWriter) link(n *htmlNode) string {
+	text := w.inlineChildren(n)
+	href := strings.TrimSpace(n.attr("href"))
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return text
+	}
+	lead, core, trail := splitSpace(text)
+	if core == "" {
+		return text
+	}
+
+	title := n.attr("title")
+	if plain := collapseSpace(strings.TrimSpace(textContent(n))); title == "" && !strings.ContainsAny(href, " <>") {
+		if plain == href && (strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://")) {
+			return lead + "<" + href + ">" + trail
+		}
+		if "mailto:"+plain == href && strings.Contains(plain, "@") {
+			return lead + "<" + plain + ">" + trail
+		}
+	}
+	return lead + "[" + core + "]" + w.destination(href, title) + trail
+}
+
+// image converts an <img> element.
+func (w *markdownWriter) image(n *htmlNode) string {
+	src := strings.TrimSpace(n.attr("src"))
+	if src == "" {
+		return ""
+	}
+	alt := escapeMarkdown(collapseSpace(strings.TrimSpace(n.attr("alt"))))
+	return "![" + alt + "]" + w.destination(src, n.attr("title"))
+}
+
+// destination returns the link destination and title of a link or image, either
+// inline or as a reference to a link definition.
+func (w *markdownWriter) destination(url, title string) string {
+	url = escapeLinkURL(url)
+	if title != "" {
+		title = ` "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(collapseSpace(title)) + `"`
+	}
+	if !w.converter.referenceLinks {
+		return "(" + url + title + ")"
+	}
+
+	key := url + title
+	ref, ok := w.refs[key]
+	if !ok {
+		ref = len(w.refs) + 1
+		w.refs[key] = ref
+		w.defs = append(w.defs, "["+strconv.Itoa(ref)+"]: "+url+title)
+	}
+	return "[" + strconv.Itoa(ref) + "]"
+}
+
+// escapeLinkURL percent-encodes the characters that would end a link destination.
+var escapeLinkURL = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace
+
+// list converts a <ul> or <ol> element. Lists are tight unless an item contains
+// block elements other than nested lists.
+func (w *markdownWriter) list(n *htmlNode) string {
+	start := 1
+	if n.tag == "ol" {
+		if s, err := strconv.Atoi(strings.TrimSpace(n.attr("start"))); err == nil && s >= 0 {
+			start = s
+		}
+	}
+
+	// Nested lists written directly inside the list, rather than in an item,
+	// belong to the preceding item.
+	var items [][]*htmlNode
+	loose := false
+	for _, c := range n.children {
+		switch {
+		case c.kind == htmlElement && c.tag == "li":
+			items = append(items, c.children)
+			for _, gc := range c.children {
+				if gc.kind == htmlElement && isBlockElement(gc.tag) && gc.tag != "ul" && gc.tag != "ol" {
+					loose = true
+				}
+			}
+		case c.kind == htmlText && strings.TrimSpace(c.text) == "":
+		case len(items) > 0:
+			items[len(items)-1] = append(items[len(items)-1], c)
+		default:
+			items = append(items, []*htmlNode{c})
+		}
+	}
+
+	separator := "\n"
+	if loose {
+		separator = "\n\n"
+	}
+	var b strings.Builder
+	for i, item := range items {
+		marker := "-"
+		if n.tag == "ol" {
+			marker = strconv.Itoa(start+i) + "."
+		}
+		if i > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(marker)
+		body := strings.Join(w.blocks(item), separator)
+		if body == "" {
+			continue
+		}
+		indent := strings.Repeat(" ", len(marker)+1)
+		for j, line := range strings.Split(body, "\n") {
+			switch {
+			case j == 0:
+				b.WriteString(" " + line)
+			case line == "":
+				b.WriteString("\n")
+			default:
+				b.WriteString("\n" + indent + line)
+			}
+		}
+	}
+	return b.String()
+}
+
+// table converts a <table> element to a GitHub Flavored Markdown table, with
+// the first row as the header. Tables that Markdown cannot represent, with
+// merged cells or block content in cells, are kept as HTML in UnknownHTMLKeep mode.
+func (w *markdownWriter) table(n *htmlNode) []string {
+	var rows [][]*htmlNode
+	var caption *htmlNode
+	complex := false
+	var collect func(*htmlNode)
+	collect = func(parent *htmlNode) {
+		for _, c := range parent.children {
+			if c.kind != htmlElement {
+				continue
+			}
+			switch c.tag {
+			case "caption":
+				caption = c
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			case "tr":
+				var cells []*htmlNode
+				for _, cell := range c.children {
+					if cell.kind != htmlElement || (cell.tag != "td" && cell.tag != "th") {
+						continue
+					}
+					cells = append(cells, cell)
+					if span(cell.attr("colspan")) > 1 || span(cell.attr("rowspan")) > 1 || hasComplexContent(cell) {
+						complex = true
+					}
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collect(n)
+
+	if complex && w.converter.unknownHTML == UnknownHTMLKeep {
+		return []string{rawHTMLBlock(n)}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+
+	var out []string
+	if caption != nil {
+		if text := formatInline(w.inlineChildren(caption)); text != "" {
+			out = append(out, text)
+		}
+	}
+
+	var lines []string
+	for i, row := range rows {
+		cells := make([]string, columns)
+		for j := range cells {
+			cells[j] = ""
+			if j < len(row) {
+				cells[j] = w.tableCell(row[j])
+			}
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			delimiters := make([]string, columns)
+			for j := range delimiters {
+				delimiters[j] = "---"
+				if j < len(row) {
+					switch cellAlignment(row[j]) {
+					case "left":
+						delimiters[j] = ":---"
+					case "center":
+						delimiters[j] = ":---:"
+					case "right":
+						delimiters[j] = "---:"
+					}
+				}
+			}
+			lines = append(lines, "| "+strings.Join(delimiters, " | ")+" |")
+		}
+	}
+	return append(out, strings.Join(lines, "\n"))
+}
+
+// tableCell converts the content of a table cell to a single line.
+func (w *markdownWriter) tableCell(cell *htmlNode) string {
+	text := strings.Join(w.blocks(cell.children), "<br>")
+	text = strings.ReplaceAll(text, "  \n", "<br>")
+	text = strings.ReplaceAll(text, "\n", " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// hasComplexContent reports whether a table cell contains elements that cannot
+// be written on a single line.
+func hasComplexContent(n *htmlNode) bool {
+	for _, c := range n.children {
+		if c.kind != htmlElement {
+			continue
+		}
+		switch c.tag {
+		case "table", "ul", "ol", "pre", "blockquote", "h1", "h2", "h3", "h4", "h5", "h6":
+			return true
+		}
+		if hasComplexContent(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// span parses a colspan or rowspan attribute.
+func span(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+// cellAlignment returns the text alignment of a table cell from its align
+// attribute or its style.
+func cellAlignment(cell *htmlNode) string {
+	if align := strings.ToLower(strings.TrimSpace(cell.attr("align"))); align != "" {
+		return align
+	}
+	for _, decl := range strings.Split(cell.attr("style"), ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "text-align") {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// codeBlock converts a <pre> element to a fenced code block, with the language
+// from a language-* or lang-* class, or a SyntaxHighlighter brush.
+func codeBlock(n *htmlNode) string {
+	code := strings.ReplaceAll(textContent(n), "\r\n", "\n")
+	code = strings.TrimPrefix(code, "\n")
+	code = strings.TrimRight(code, "\n")
+
+	language := codeLanguage(n)
+	if language == "" {
+		for _, c := range n.children {
+			if c.kind == htmlElement && c.tag == "code" {
+				language = codeLanguage(c)
+				break
+			}
+		}
+	}
+
+	fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+func codeLanguage(n *htmlNode) string {
+	classes := strings.Fields(n.attr("class"))
+	for i, class := range classes {
+		switch {
+		case strings.HasPrefix(class, "language-"):
+			return strings.TrimPrefix(class, "language-")
+		case strings.HasPrefix(class, "lang-"):
+			return strings.TrimPrefix(class, "lang-")
+		case class == "brush:" && i+1 < len(classes):
+			return strings.TrimSuffix(classes[i+1], ";")
+		case strings.HasPrefix(class, "brush:"):
+			return strings.TrimSuffix(strings.TrimPrefix(class, "brush:"), ";")
+		}
+	}
+	return ""
+}
+
+// codeSpan writes text as a code span, with a backtick string longer than any
+// run of backticks in the text.
+func codeSpan(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if text[0] == '`' || text[len(text)-1] == '`' || (text[0] == ' ' && text[len(text)-1] == ' ') {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// rawHTMLBlock writes an element as an HTML block. Blank lines, which would end
+// the block, are removed.
+func rawHTMLBlock(n *htmlNode) string {
+	var b strings.Builder
+	renderHTML(&b, n)
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// emphasize wraps text in an emphasis delimiter, leaving surrounding whitespace
+// outside of it so that the delimiters are recognized.
+func emphasize(text, delim string) string {
+	lead, core, trail := splitSpace(text)
+	if core == "" {
+		return text
+	}
+	return lead + delim + core + delim + trail
+}
+
+// splitSpace splits text into its leading whitespace, its content and its
+// trailing whitespace.
+func splitSpace(text string) (lead, core, trail string) {
+	core = strings.TrimLeft(text, " \n")
+	lead = text[:len(text)-len(core)]
+	trimmed := strings.TrimRight(core, " \n")
+	return lead, trimmed, core[len(trimmed):]
+}
+
+// collapseSpace replaces each run of HTML whitespace with a single space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		if isHTMLSpace(s[i]) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// formatInline turns converted inline content into the text of a paragraph.
+// Lines are trimmed, a newline from <br> becomes a hard line break and an empty
+// line between two <br> a paragraph break, and characters that would start a
+// block at the beginning of a line are escaped.
+func formatInline(s string) string {
+	var b strings.Builder
+	blank := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Trim(collapseSpace(line), " ")
+		if strings.TrimSpace(line) == "" {
+			// Also skips lines made of non-breaking spaces, such as <p>&nbsp;</p>
+			blank = true
+			continue
+		}
+		if b.Len() > 0 {
+			if blank {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("  \n")
+			}
+		}
+		blank = false
+		b.WriteString(escapeLineStart(line))
+	}
+	return b.String()
+}
+
+// escapeMarkdown escapes the characters of text that Markdown would read as
+// inline syntax.
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '*', '`', '[', ']':
+			b.WriteByte('\\')
+		case '_':
+			// Underscores inside words never delimit emphasis.
+			if i == 0 || i == len(s)-1 || !isWordByte(s[i-1]) || !isWordByte(s[i+1]) {
+				b.WriteByte('\\')
+			}
+		case '<':
+			if i+1 < len(s) && (isASCIILetter(s[i+1]) || strings.IndexByte("/!?", s[i+1]) >= 0) {
+				b.WriteByte('\\')
+			}
+		case '&':
+			if isEntityAt(s, i+1) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isWordByte(c byte) bool {
+	return isASCIILetter(c) || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+// isEntityAt reports whether s has the rest of a character reference at i,
+// such as "amp;" or "#39;".
+func isEntityAt(s string, i int) bool {
+	if i < len(s) && s[i] == '#' {
+		i++
+	}
+	n := 0
+	for i+n < len(s) && (isASCIILetter(s[i+n]) || (s[i+n] >= '0' && s[i+n] <= '9')) {
+		n++
+	}
+	return n > 0 && i+n < len(s) && s[i+n] == ';'
+}
+
+// escapeLineStart escapes the markers that would start a heading, blockquote,
+// list, thematic break or code fence at the beginning of a line.
+func escapeLineStart(line string) string {
+	switch line[0] {
+	case '>':
+		return `\` + line
+	case '#':
+		rest := strings.TrimLeft(line, "#")
+		if len(line)-len(rest) <= 6 && (rest == "" || rest[0] == ' ') {
+			return `\` + line
+		}
+	case '-', '+':
+		if len(line) == 1 || line[1] == ' ' || line[1] == line[0] {
+			return `\` + line
+		}
+	case '=':
+		if strings.Trim(line, "= ") == "" {
+			return `\` + line
+		}
+	case '~':
+		if strings.HasPrefix(line, "~~~") {
+			return `\` + line
+		}
+	}
+
+	digits := 0
+	for digits < len(line) && digits < 10 && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits < 10 && digits < len(line) && (line[digits] == '.' || line[digits] == ')') &&
+		(digits+1 == len(line) || line[digits+1] == ' ') {
+		return line[:digits] + `\` + line[digits:]
+	}
+	return line
+}
@@ -0,0 +1,186 @@
+package wxr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "headings and inline formatting",
+			content: "<h2>Title</h2>\n<p>Hello <strong>world</strong>, <em>you </em>and <del>them</del>.</p>",
+			want:    "## Title\n\nHello **world**, *you* and ~~them~~.",
+		},
+		{
+			name:    "line breaks and empty paragraphs",
+			content: "<p>one<br>two<br />\n<br>three</p><p>&nbsp;</p><p>four</p>",
+			want:    "one  \ntwo\n\nthree\n\nfour",
+		},
+		{
+			name:    "nested and ordered lists",
+			content: "<ul><li>a<ul><li>b</li></ul></li><li>c</ul><ol start=\"3\"><li>x</li><li>y</li></ol>",
+			want:    "- a\n  - b\n- c\n\n3. x\n4. y",
+		},
+		{
+			name:    "loose list",
+			content: "<ol><li><p>first</p><p>more</p></li><li><p>second</p></li></ol>",
+			want:    "1. first\n\n   more\n\n2. second",
+		},
+		{
+			name:    "nested list outside of an item",
+			content: "<ul><li>a</li><ul><li>b</li></ul></ul>",
+			want:    "- a\n  - b",
+		},
+		{
+			name:    "links and images",
+			content: `<p><a href="https://example.com/a b" title="A &quot;site&quot;">the site</a> <a href="https://example.com">https://example.com</a> <a href="mailto:me@example.com">me@example.com</a> <img src="/p.jpg" alt="A [photo]"></p>`,
+			want:    `[the site](https://example.com/a%20b "A \"site\"") <https://example.com> <me@example.com> ![A \[photo\]](/p.jpg)`,
+		},
+		{
+			name:    "blockquote",
+			content: "<blockquote><p>one</p><p>two</p></blockquote>",
+			want:    "> one\n>\n> two",
+		},
+		{
+			name:    "code",
+			content: "<p>Run <code>go  test</code> or <code>a`b</code></p><pre class=\"wp-block-code\"><code class=\"language-go\">fmt.Println(\"&lt;hi&gt;\")\n\n```\n</code></pre>",
+			want:    "Run `go test` or ``a`b``\n\n````go\nfmt.Println(\"<hi>\")\n\n```\n````",
+		},
+		{
+			name:    "table",
+			content: `<table><thead><tr><th>Name</th><th style="text-align: right">Price</th></tr></thead><tbody><tr><td><a href="/a">A</a></td><td>1|2</td></tr><tr><td>B</td></tr></tbody></table>`,
+			want:    "| Name | Price |\n| --- | ---: |\n| [A](/a) | 1\\|2 |\n| B |  |",
+		},
+		{
+			name:    "figure and caption",
+			content: `<figure class="wp-block-image"><img src="/p.jpg" alt="Photo"><figcaption>A <strong>nice</strong> photo</figcaption></figure>`,
+			want:    "![Photo](/p.jpg)\n\n*A **nice** photo*",
+		},
+		{
+			name:    "escaping",
+			content: "<p>2*3 = 6, snake_case, _x_, [a] &amp;amp; &lt;b&gt;</p><p>1. not a list</p><p># not a heading</p><p>- not an item</p>",
+			want:    "2\\*3 = 6, snake_case, \\_x\\_, \\[a\\] \\&amp; \\<b>\n\n1\\. not a list\n\n\\# not a heading\n\n\\- not an item",
+		},
+		{
+			name:    "unclosed paragraphs",
+			content: "<p>one<p>two<div>three</div>",
+			want:    "one\n\ntwo\n\nthree",
+		},
+		{
+			name:    "scripts and comments",
+			content: "<p>Text<!-- note --></p><script>alert(1)</script><style>p{}</style>",
+			want:    "Text",
+		},
+		{
+			name: "blocks",
+			content: "<!-- wp:heading -->\n<h2>Intro</h2>\n<!-- /wp:heading -->\n\n" +
+				"<!-- wp:more -->\n<!--more-->\n<!-- /wp:more -->\n\n" +
+				"<!-- wp:embed {\"url\":\"https://youtu.be/x\",\"providerNameSlug\":\"youtube\"} -->\n" +
+				"<figure class=\"wp-block-embed\"><div class=\"wp-block-embed__wrapper\">\nhttps://youtu.be/x\n</div></figure>\n<!-- /wp:embed -->",
+			want: "## Intro\n\n<https://youtu.be/x>",
+		},
+		{
+			name:    "caption shortcode",
+			content: `[caption id="attachment_5" align="alignleft" width="300"]<img src="/p.jpg" alt="Photo"> The caption[/caption]`,
+			want:    "![Photo](/p.jpg)\n\n*The caption*",
+		},
+		{
+			name:    "unknown HTML is kept",
+			content: `<p>Note<sup>1</sup> <u>under <em>lined</em></u></p><iframe src="https://example.com/embed"></iframe>`,
+			want:    "Note<sup>1</sup> <u>under *lined*</u>\n\n<iframe src=\"https://example.com/embed\"></iframe>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToMarkdown(tt.content); got != tt.want {
+				t.Errorf("ToMarkdown() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownConverter_ReferenceLinks(t *testing.T) {
+	content := `<p><a href="https://a.example">A</a>, <a href="https://b.example" title="B">B</a> and <a href="https://a.example">A again</a></p><p><img src="/p.jpg" alt="P"></p>`
+
+	got := NewMarkdownConverter().WithReferenceLinks(true).Convert(content)
+	want := "[A][1], [B][2] and [A again][1]\n\n![P][3]\n\n" +
+		"[1]: https://a.example\n[2]: https://b.example \"B\"\n[3]: /p.jpg"
+	if got != want {
+		t.Errorf("Convert() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarkdownConverter_UnknownHTML(t *testing.T) {
+	content := `<p>Note<sup>1</sup></p><details><summary>More</summary><p>Hidden</p></details>`
+
+	tests := []struct {
+		mode UnknownHTMLMode
+		want string
+	}{
+		{UnknownHTMLKeep, "Note<sup>1</sup>\n\n<details><summary>More</summary><p>Hidden</p></details>"},
+		{UnknownHTMLText, "Note1\n\nMore\n\nHidden"},
+		{UnknownHTMLDrop, "Note"},
+	}
+	for _, tt := range tests {
+		if got := NewMarkdownConverter().WithUnknownHTML(tt.mode).Convert(content); got != tt.want {
+			t.Errorf("mode %d: Convert() =\n%s\nwant:\n%s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestToMarkdown_RawTextElements(t *testing.T) {
+	// Ⱥ is 2 bytes but lowercases to 3, so the closing tag must be found in the
+	// original text
+	for _, content := range []string{
+		"<script>ȺȺȺȺȺȺȺȺȺȺ</script><p>After</p>",
+		"<SCRIPT>ȺȺȺȺȺȺȺȺȺȺ</Script><p>After</p>",
+		"<textarea>ȺȺȺȺȺȺȺȺȺȺ</textarea><p>After</p>",
+	} {
+		if got := ToMarkdown(content); !strings.HasSuffix(got, "After") {
+			t.Errorf("ToMarkdown(%q) = %q, want the text after the element", content, got)
+		}
+	}
+}
+
+func TestMarkdownConverter_ComplexTable(t *testing.T) {
+	content := `<table><tr><td colspan="2">Wide</td></tr><tr><td>a</td><td>b</td></tr></table>`
+
+	got := NewMarkdownConverter().Convert(content)
+	if got != content {
+		t.Errorf("expected table with merged cells to be kept as HTML, got:\n%s", got)
+	}
+	got = NewMarkdownConverter().WithUnknownHTML(UnknownHTMLText).Convert(content)
+	if want := "| Wide |  |\n| --- | --- |\n| a | b |"; got != want {
+		t.Errorf("Convert() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarkdownConverter_Shortcodes(t *testing.T) {
+	content := `[gallery ids="7"] [unknown]`
+	attachments := []Attachment{{ID: 7, URL: "https://example.com/a.jpg", AltText: "A"}}
+
+	got := NewMarkdownConverter().
+		WithShortcodes(NewShortcodeRegistry().WithAttachments(attachments)).
+		Convert(content)
+	if want := "![A](https://example.com/a.jpg)\n\n\\[unknown\\]"; got != want {
+		t.Errorf("Convert() =\n%s\nwant:\n%s", got, want)
+	}
+
+	got = NewMarkdownConverter().WithShortcodes(nil).Convert(content)
+	if want := `\[gallery ids="7"\] \[unknown\]`; got != want {
+		t.Errorf("Convert() without shortcodes = %q, want %q", got, want)
+	}
+}
+
+func TestPost_Markdown(t *testing.T) {
+	post := Post{ContentRendered: "<!-- wp:paragraph -->\n<p>Hello <b>there</b></p>\n<!-- /wp:paragraph -->"}
+	if got := post.Markdown(); got != "Hello **there**" {
+		t.Errorf("Markdown() = %q", got)
+	}
+}
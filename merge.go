@@ -0,0 +1,214 @@
+package wxr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// ParseMerged parses an export split into several WXR files, such as
+// export-001.xml, export-002.xml, ..., into one Document, as if the files were a
+// single export. Attachments, authors and terms of every file are indexed
+// together, so a featured image or a category defined in one file resolves for
+// posts in another. An item that appears in more than one file, with the same
+// post ID or GUID, is kept only the first time it is read.
+//
+// The site information comes from the first file, with fields it leaves empty
+// taken from the next ones. Errors name the input they come from, such as
+// "input 2", and so does Diagnostic.Source.
+func (p *Parser) ParseMerged(ctx context.Context, readers ...io.Reader) (*Document, error) {
+	p.logger.Printf("Starting WXR parsing of %d inputs", len(readers))
+
+	m := newChannelMerger()
+	for i, r := range readers {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		name := fmt.Sprintf("input %d", i+1)
+		wxrDoc, err := p.decodeXML(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		m.add(name, wxrDoc.Channel)
+	}
+	return p.buildMerged(ctx, m)
+}
+
+// ParseGlob parses the files matching pattern, in lexical order, as one
+// export; see ParseMerged. Errors and diagnostics name the file they come from.
+func (p *Parser) ParseGlob(ctx context.Context, pattern string) (*Document, error) {
+	names, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("wxr: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("wxr: no files match %q", pattern)
+	}
+	p.logger.Printf("Starting WXR parsing of %d files", len(names))
+
+	m := newChannelMerger()
+	for _, name := range names {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		wxrDoc, err := p.decodeFile(name)
+		if err != nil {
+			return nil, err
+		}
+		m.add(name, wxrDoc.Channel)
+	}
+	return p.buildMerged(ctx, m)
+}
+
+// decodeFile decodes the WXR document in the named file.
+func (p *Parser) decodeFile(name string) (*wxr, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("wxr: %w", err)
+	}
+	defer f.Close()
+
+	wxrDoc, err := p.decodeXML(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return wxrDoc, nil
+}
+
+// buildMerged builds the Document of the merged channel.
+func (p *Parser) buildMerged(ctx context.Context, m *channelMerger) (*Document, error) {
+	if m.duplicates > 0 {
+		p.logger.Printf("Skipped %d items already read from another file", m.duplicates)
+	}
+	return p.buildDocument(ctx, &wxr{Channel: m.channel})
+}
+
+// ParseMerged is a convenience function that parses an export split into
+// several WXR files using the default parser.
+func ParseMerged(ctx context.Context, readers ...io.Reader) (*Document, error) {
+	parser := NewParser()
+	return parser.ParseMerged(ctx, readers...)
+}
+
+// ParseGlob is a convenience function that parses the WXR files matching
+// pattern as one export using the default parser.
+func ParseGlob(ctx context.Context, pattern string) (*Document, error) {
+	parser := NewParser()
+	return parser.ParseGlob(ctx, pattern)
+}
+
+// channelMerger combines the channels of the files of a split export.
+type channelMerger struct {
+	channel    channel
+	postIDs    map[int]bool
+	guids      map[string]bool
+	authors    map[string]bool // logins
+	terms      map[string]bool // taxonomy and slug
+	duplicates int
+}
+
+func newChannelMerger() *channelMerger {
+	return &channelMerger{
+		postIDs: make(map[int]bool),
+		guids:   make(map[string]bool),
+		authors: make(map[string]bool),
+		terms:   make(map[string]bool),
+	}
+}
+
+// add merges ch, read from the input called name, into the merged channel.
+func (m *channelMerger) add(name string, ch channel) {
+	merged := &m.channel
+	for _, field := range []struct{ dst, src *string }{
+		{&merged.Title, &ch.Title},
+		{&merged.Link, &ch.Link},
+		{&merged.Description, &ch.Description},
+		{&merged.PubDate, &ch.PubDate},
+		{&merged.Language, &ch.Language},
+		{&merged.Generator, &ch.Generator},
+		{&merged.WXRVersion, &ch.WXRVersion},
+		{&merged.BaseSiteURL, &ch.BaseSiteURL},
+		{&merged.BaseBlogURL, &ch.BaseBlogURL},
+		{&merged.NamespaceVersion, &ch.NamespaceVersion},
+	} {
+		if *field.dst == "" {
+			*field.dst = *field.src
+		}
+	}
+
+	for _, author := range ch.Authors {
+		if !m.authors[author.Login] {
+			m.authors[author.Login] = true
+			merged.Authors = append(merged.Authors, author)
+		}
+	}
+	for _, category := range ch.Categories {
+		if m.addTerm("category", category.NiceName) {
+			merged.Categories = append(merged.Categories, category)
+		}
+	}
+	for _, tag := range ch.Tags {
+		if m.addTerm("post_tag", tag.Slug) {
+			merged.Tags = append(merged.Tags, tag)
+		}
+	}
+	for _, term := range ch.Terms {
+		if m.addTerm(term.Taxonomy, term.Slug) {
+			merged.Terms = append(merged.Terms, term)
+		}
+	}
+
+	for _, node := range ch.Extra {
+		if !containsNode(merged.Extra, node) {
+			merged.Extra = append(merged.Extra, node)
+		}
+	}
+	for _, d := range ch.Diagnostics {
+		d.Source = name
+		merged.Diagnostics = append(merged.Diagnostics, d)
+	}
+
+	for _, it := range ch.Items {
+		if (it.PostID != 0 && m.postIDs[it.PostID]) || (it.GUID != "" && m.guids[it.GUID]) {
+			m.duplicates++
+			continue
+		}
+		if it.PostID != 0 {
+			m.postIDs[it.PostID] = true
+		}
+		if it.GUID != "" {
+			m.guids[it.GUID] = true
+		}
+		merged.Items = append(merged.Items, it)
+	}
+}
+
+// addTerm records the term of taxonomy with slug, and reports whether it was
+// not seen before.
+func (m *channelMerger) addTerm(taxonomy, slug string) bool {
+	key := taxonomy + "/" + slug
+	if m.terms[key] {
+		return false
+	}
+	m.terms[key] = true
+	return true
+}
+
+// containsNode reports whether nodes holds a node equal to n.
+func containsNode(nodes Nodes, n Node) bool {
+	for _, node := range nodes {
+		if reflect.DeepEqual(node, n) {
+			return true
+		}
+	}
+	return false
+}
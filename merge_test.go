@@ -0,0 +1,136 @@
+package wxr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mergeHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+`
+
+var mergeFiles = []string{
+	mergeHeader + `	<title>Split Site</title>
+	<wp:base_site_url>https://example.com</wp:base_site_url>
+	<wp:author><wp:author_login>alice</wp:author_login><wp:author_display_name>Alice</wp:author_display_name></wp:author>
+	<wp:category><wp:term_id>1</wp:term_id><wp:category_nicename>news</wp:category_nicename><wp:cat_name>News</wp:cat_name></wp:category>
+	<item>
+		<title>First</title>
+		<guid isPermaLink="false">https://example.com/?p=1</guid>
+		<dc:creator>alice</dc:creator>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+		<category domain="category" nicename="news"><![CDATA[News]]></category>
+		<wp:postmeta><wp:meta_key>_thumbnail_id</wp:meta_key><wp:meta_value>10</wp:meta_value></wp:postmeta>
+	</item>
+</channel>
+</rss>`,
+	mergeHeader + `	<title>Split Site</title>
+	<language>en-US</language>
+	<wp:author><wp:author_login>alice</wp:author_login><wp:author_display_name>Alice</wp:author_display_name></wp:author>
+	<wp:author><wp:author_login>bob</wp:author_login><wp:author_display_name>Bob</wp:author_display_name></wp:author>
+	<wp:category><wp:term_id>1</wp:term_id><wp:category_nicename>news</wp:category_nicename><wp:cat_name>News</wp:cat_name></wp:category>
+	<item>
+		<title>Photo</title>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_type>attachment</wp:post_type>
+		<wp:status>inherit</wp:status>
+		<wp:attachment_url>https://example.com/wp-content/uploads/photo.jpg</wp:attachment_url>
+	</item>
+	<item>
+		<title>First (again)</title>
+		<guid isPermaLink="false">https://example.com/?p=1</guid>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+	<item>
+		<title>Second</title>
+		<dc:creator>bob</dc:creator>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+</channel>
+</rss>`,
+}
+
+// checkMerged checks the document built from mergeFiles.
+func checkMerged(t *testing.T, doc *Document) {
+	t.Helper()
+	if len(doc.Posts) != 2 || doc.Posts[0].TitleRendered != "First" || doc.Posts[1].TitleRendered != "Second" {
+		t.Fatalf("Posts = %+v, want First and Second", doc.Posts)
+	}
+	if want := "https://example.com/wp-content/uploads/photo.jpg"; doc.Posts[0].FeaturedImage != want {
+		t.Errorf("FeaturedImage = %q, want %q", doc.Posts[0].FeaturedImage, want)
+	}
+	if doc.Posts[1].Author != "Bob" {
+		t.Errorf("Author = %q, want Bob", doc.Posts[1].Author)
+	}
+	if len(doc.Authors) != 2 {
+		t.Errorf("Authors = %+v, want alice and bob", doc.Authors)
+	}
+	if terms := doc.Taxonomies["category"].Terms; len(terms) != 1 {
+		t.Errorf("category terms = %+v, want news once", terms)
+	}
+	if len(doc.Attachments) != 1 {
+		t.Errorf("Attachments = %+v, want 1", doc.Attachments)
+	}
+	if doc.Site.Title != "Split Site" || doc.Site.BaseSiteURL != "https://example.com" || doc.Site.Language != "en-US" {
+		t.Errorf("Site = %+v", doc.Site)
+	}
+}
+
+func TestParseMerged(t *testing.T) {
+	doc, err := ParseMerged(context.Background(), strings.NewReader(mergeFiles[0]), strings.NewReader(mergeFiles[1]))
+	if err != nil {
+		t.Fatalf("ParseMerged() error = %v", err)
+	}
+	checkMerged(t, doc)
+
+	_, err = ParseMerged(context.Background(), strings.NewReader(mergeFiles[0]), strings.NewReader("<rss><channel>"))
+	if err == nil || !strings.HasPrefix(err.Error(), "input 2: ") {
+		t.Errorf("expected an error naming input 2, got %v", err)
+	}
+}
+
+func TestParseGlob(t *testing.T) {
+	dir := t.TempDir()
+	for i, content := range mergeFiles {
+		name := filepath.Join(dir, []string{"export-001.xml", "export-002.xml"}[i])
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := ParseGlob(context.Background(), filepath.Join(dir, "export-*.xml"))
+	if err != nil {
+		t.Fatalf("ParseGlob() error = %v", err)
+	}
+	checkMerged(t, doc)
+
+	if _, err := ParseGlob(context.Background(), filepath.Join(dir, "missing-*.xml")); err == nil {
+		t.Error("expected an error when no file matches")
+	}
+
+	// Diagnostics name the file they come from
+	truncated := filepath.Join(dir, "export-003.xml")
+	if err := os.WriteFile(truncated, []byte(mergeHeader+"\t<item><title>Cut"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err = NewParser().WithRecovery(true).ParseGlob(context.Background(), filepath.Join(dir, "export-*.xml"))
+	if err != nil {
+		t.Fatalf("ParseGlob() error = %v", err)
+	}
+	if len(doc.Diagnostics) != 1 || doc.Diagnostics[0].Source != truncated {
+		t.Errorf("Diagnostics = %v, want one from %s", doc.Diagnostics, truncated)
+	}
+}
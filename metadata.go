@@ -29,3 +29,34 @@ func cleanMetaValue(value string) string {
 	}
 	return trimmed
 }
+
+// MetaEntry is a meta field as exported: a wp:postmeta or wp:commentmeta
+// element, with its key and value unchanged.
+type MetaEntry struct {
+	Key   string
+	Value string
+}
+
+// metaEntries returns the meta entries unchanged and in order. The result is
+// not nil, so that the encoder can tell parsed values from ones built by hand.
+func metaEntries(entries []postMeta) []MetaEntry {
+	out := make([]MetaEntry, len(entries))
+	for i, m := range entries {
+		out[i] = MetaEntry{Key: m.Key, Value: m.Value}
+	}
+	return out
+}
+
+// metaMap converts meta entries into a map of cleaned values.
+// Entries with an empty key or an empty or "null" value are dropped.
+func metaMap(entries []postMeta) map[string]string {
+	meta := make(map[string]string)
+	for _, m := range entries {
+		key := strings.TrimSpace(m.Key)
+		value := cleanMetaValue(m.Value)
+		if key != "" && value != "" {
+			meta[key] = value
+		}
+	}
+	return meta
+}
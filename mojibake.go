@@ -0,0 +1,160 @@
+package wxr
+
+import (
+	"encoding/xml"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// RepairMojibake repairs UTF-8 text that was read as windows-1252 or ISO-8859-1
+// and encoded to UTF-8 again, as happens when a latin1 database table is
+// converted to utf8, so that "SÃ£o Paulo" becomes "São Paulo". It undoes one
+// layer of encoding; see RepairMojibakeN. It reports whether s was changed.
+//
+// The string is repaired only as a whole: every character must map back to a
+// windows-1252 byte, the bytes must be valid UTF-8, and the characters they
+// encode must be plausible, that is Latin letters and symbols (U+00A0 to
+// U+024F and those of windows-1252) or characters of three and four bytes
+// such as “ ” € or emoji. Text that mixes correct and garbled characters, and
+// correct text such as "CAFÉ\u00a0!" whose bytes happen to form other
+// characters, are returned unchanged. Double-encoded Greek, Cyrillic or other
+// two-byte scripts are not repaired.
+func RepairMojibake(s string) (string, bool) {
+	return RepairMojibakeN(s, 1)
+}
+
+// RepairMojibakeN is like RepairMojibake but undoes up to passes layers of
+// encoding, for text converted more than once, such as "SÃƒÂ£o" for "São".
+func RepairMojibakeN(s string, passes int) (string, bool) {
+	changed := false
+	for range passes {
+		repaired, ok := repairMojibakePass(s)
+		if !ok {
+			break
+		}
+		s, changed = repaired, true
+	}
+	return s, changed
+}
+
+// repairMojibakePass undoes one layer of double encoding of s.
+func repairMojibakePass(s string) (string, bool) {
+	// Every garbled sequence starts with the character of a UTF-8 lead byte
+	if !strings.ContainsFunc(s, func(r rune) bool { return r >= 0xC2 && r <= 0xF4 }) {
+		return s, false
+	}
+
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := windows1252Byte(r)
+		if !ok {
+			return s, false
+		}
+		encoded = append(encoded, b)
+	}
+	if !utf8.Valid(encoded) {
+		return s, false
+	}
+	repaired := string(encoded)
+	for _, r := range repaired {
+		if r >= utf8.RuneSelf && r < 0x800 && !isLatinRune(r) {
+			return s, false
+		}
+	}
+	return repaired, true
+}
+
+// isLatinRune reports whether r is a Latin letter or symbol of U+00A0 to
+// U+024F or one of windows-1252, such as ˜, which text converted more than once
+// holds between layers.
+func isLatinRune(r rune) bool {
+	if r >= 0xA0 && r <= 0x24F {
+		return true
+	}
+	_, ok := windows1252Byte(r)
+	return ok && r >= 0x100
+}
+
+// windows1252Byte returns the byte that r stands for in windows-1252, or in
+// ISO-8859-1 for the C1 controls windows-1252 does not define.
+func windows1252Byte(r rune) (byte, bool) {
+	if r < 0x100 {
+		return byte(r), true
+	}
+	for i, c := range windows1252[:0x20] {
+		if c == r {
+			return byte(0x80 + i), true
+		}
+	}
+	return 0, false
+}
+
+// repairItemMojibake repairs the text fields of it in place and returns the
+// names of the elements that changed. Serialized PHP meta values are left alone,
+// since repairing them would break their string lengths.
+func repairItemMojibake(it *item, passes int) []string {
+	var fields []string
+	repair := func(s *string, field string) {
+		if repaired, ok := RepairMojibakeN(*s, passes); ok {
+			*s = repaired
+			if !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	repair(&it.Title, "title")
+	repair(&it.ContentEncoded, "content:encoded")
+	repair(&it.ExcerptEncoded, "excerpt:encoded")
+	for i := range it.Categories {
+		repair(&it.Categories[i].Value, "category")
+	}
+	for i := range it.PostMeta {
+		if !IsPHPSerialized(it.PostMeta[i].Value) {
+			repair(&it.PostMeta[i].Value, "wp:postmeta")
+		}
+	}
+	for i := range it.Comments {
+		repair(&it.Comments[i].Author, "wp:comment")
+		repair(&it.Comments[i].Content, "wp:comment")
+	}
+	return fields
+}
+
+// repairChannelMojibake repairs the text of the channel element that starts
+// with start, which has just been decoded into ch, and reports whether it changed.
+func repairChannelMojibake(ch *channel, start xml.StartElement, passes int) bool {
+	changed := false
+	repair := func(s *string) {
+		if repaired, ok := RepairMojibakeN(*s, passes); ok {
+			*s = repaired
+			changed = true
+		}
+	}
+
+	switch {
+	case start.Name.Space == "" && start.Name.Local == "title":
+		repair(&ch.Title)
+	case start.Name.Space == "" && start.Name.Local == "description":
+		repair(&ch.Description)
+	case start.Name.Space == wpNamespace && start.Name.Local == "author":
+		author := &ch.Authors[len(ch.Authors)-1]
+		repair(&author.DisplayName)
+		repair(&author.FirstName)
+		repair(&author.LastName)
+	case start.Name.Space == wpNamespace && start.Name.Local == "category":
+		category := &ch.Categories[len(ch.Categories)-1]
+		repair(&category.Name)
+		repair(&category.Description)
+	case start.Name.Space == wpNamespace && start.Name.Local == "tag":
+		tag := &ch.Tags[len(ch.Tags)-1]
+		repair(&tag.Name)
+		repair(&tag.Description)
+	case start.Name.Space == wpNamespace && start.Name.Local == "term":
+		term := &ch.Terms[len(ch.Terms)-1]
+		repair(&term.Name)
+		repair(&term.Description)
+	}
+	return changed
+}
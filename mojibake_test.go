@@ -0,0 +1,143 @@
+package wxr
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRepairMojibake(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		changed bool
+	}{
+		{"latin1 accents", "SÃ£o Paulo, ConceiÃ§Ã£o", "São Paulo, Conceição", true},
+		{"windows-1252 punctuation", "Itâ€™s â€œquotedâ€\u009d â€“ done", "It’s “quoted” – done", true},
+		{"euro sign", "â‚¬10", "€10", true},
+		{"emoji", "ðŸ˜€", "😀", true},
+		{"one layer of three", "SÃƒÂ£o", "SÃ£o", true},
+		{"mixed", "São and SÃ£o", "São and SÃ£o", false},
+		{"correct text", "Não, São Paulo é ótimo", "Não, São Paulo é ótimo", false},
+		{"capital A tilde", "NÃO", "NÃO", false},
+		{"capital E acute before no-break space", "CAFÉ\u00a0!", "CAFÉ\u00a0!", false},
+		{"french quotes", "« ÉTÉ\u00a0»", "« ÉTÉ\u00a0»", false},
+		{"O slash before one half", "Ø½ inch", "Ø½ inch", false},
+		{"C1 control", "Â\u0085", "Â\u0085", false},
+		{"ascii", "Hello", "Hello", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := RepairMojibake(tt.input)
+			if got != tt.want || changed != tt.changed {
+				t.Errorf("RepairMojibake(%q) = %q, %v, want %q, %v", tt.input, got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestRepairMojibakeN(t *testing.T) {
+	if got, changed := RepairMojibakeN("SÃƒÂ£o", 2); got != "São" || !changed {
+		t.Errorf("RepairMojibakeN(2) = %q, %v, want %q, true", got, changed, "São")
+	}
+	if got, changed := RepairMojibakeN("Ã°Å¸Ëœâ‚¬", 2); got != "😀" || !changed {
+		t.Errorf("RepairMojibakeN(2) = %q, %v, want %q, true", got, changed, "😀")
+	}
+	if got, changed := RepairMojibakeN("SÃ£o", 3); got != "São" || !changed {
+		t.Errorf("RepairMojibakeN(3) = %q, %v, want %q, true", got, changed, "São")
+	}
+	if got, changed := RepairMojibakeN("SÃ£o", 0); got != "SÃ£o" || changed {
+		t.Errorf("RepairMojibakeN(0) = %q, %v, want it unchanged", got, changed)
+	}
+}
+
+func TestParser_WithMojibakeRepair(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>NotÃ­cias de SÃ£o Paulo</title>
+	<wp:category>
+		<wp:term_id>3</wp:term_id>
+		<wp:category_nicename>regiao</wp:category_nicename>
+		<wp:cat_name>RegiÃ£o</wp:cat_name>
+	</wp:category>
+	<item>
+		<title>Bem-vindo</title>
+		<content:encoded><![CDATA[<p>Texto correto.</p>]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+	<item>
+		<title>ConceiÃƒÂ§ÃƒÂ£o</title>
+		<content:encoded><![CDATA[<p>PraÃ§a da SÃ©</p>]]></content:encoded>
+		<category domain="category" nicename="regiao"><![CDATA[RegiÃ£o]]></category>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+		<wp:postmeta>
+			<wp:meta_key>cidade</wp:meta_key>
+			<wp:meta_value>SÃ£o Paulo</wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key>serialized</wp:meta_key>
+			<wp:meta_value><![CDATA[a:1:{i:0;s:5:"SÃ£o";}]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+</channel>
+</rss>`
+
+	doc, err := ParseDocument(context.Background(), strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if doc.Posts[1].TitleRendered != "ConceiÃƒÂ§ÃƒÂ£o" || len(doc.Diagnostics) != 0 {
+		t.Errorf("expected no repair by default, got %q and %v", doc.Posts[1].TitleRendered, doc.Diagnostics)
+	}
+
+	doc, err = NewParser().WithMojibakeRepair(true).WithMojibakePasses(2).ParseDocument(context.Background(), strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if doc.Site.Title != "Notícias de São Paulo" {
+		t.Errorf("Site.Title = %q", doc.Site.Title)
+	}
+	if term := doc.Taxonomies["category"].Terms[0]; term.Name != "Região" {
+		t.Errorf("term name = %q, want %q", term.Name, "Região")
+	}
+
+	post := doc.Posts[1]
+	if post.TitleRendered != "Conceição" {
+		t.Errorf("TitleRendered = %q", post.TitleRendered)
+	}
+	if post.ContentRendered != "<p>Praça da Sé</p>" {
+		t.Errorf("ContentRendered = %q", post.ContentRendered)
+	}
+	if len(post.Categories) != 1 || post.Categories[0] != "Região" {
+		t.Errorf("Categories = %v", post.Categories)
+	}
+	if post.Meta["cidade"] != "São Paulo" {
+		t.Errorf("Meta[cidade] = %q", post.Meta["cidade"])
+	}
+	if want := `a:1:{i:0;s:5:"SÃ£o";}`; post.Meta["serialized"] != want {
+		t.Errorf("serialized meta = %q, want it unchanged", post.Meta["serialized"])
+	}
+
+	want := []Diagnostic{
+		{Action: DiagnosticRepaired, Message: "double-encoded UTF-8 in title"},
+		{Action: DiagnosticRepaired, Message: "double-encoded UTF-8 in wp:category"},
+		{Action: DiagnosticRepaired, Item: 2, PostID: 2, Message: "double-encoded UTF-8 in title, content:encoded, category, wp:postmeta"},
+	}
+	if len(doc.Diagnostics) != len(want) {
+		t.Fatalf("Diagnostics = %v, want %v", doc.Diagnostics, want)
+	}
+	for i, d := range doc.Diagnostics {
+		if d != want[i] {
+			t.Errorf("Diagnostics[%d] = %+v, want %+v", i, d, want[i])
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package wxr
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Node is an XML element the package does not map to a field, such as
+// wp:comment_status, wp:is_sticky or an element added by a plugin. Nodes keep
+// the namespace, attributes and content of the element, so that no data in an
+// export is lost and plugin data can be read after parsing.
+type Node struct {
+	// Name is the namespace URI and local name of the element. Elements with an
+	// undeclared prefix have the prefix as their namespace.
+	Name xml.Name
+
+	// Attrs are the attributes of the element, without namespace declarations.
+	Attrs []xml.Attr
+
+	// Text is the character data directly inside the element, including CDATA
+	// sections, as written in the export.
+	Text string
+
+	// Children are the child elements, in document order.
+	Children Nodes
+}
+
+// Nodes is a list of XML elements.
+type Nodes []Node
+
+// Value returns the trimmed text of the element.
+func (n Node) Value() string {
+	return strings.TrimSpace(n.Text)
+}
+
+// Attr returns the value of the attribute with the given local name, and
+// whether the element has it.
+func (n Node) Attr(local string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Find returns the first element with the given namespace and local name, and
+// whether there is one. An empty space matches any namespace.
+func (ns Nodes) Find(space, local string) (Node, bool) {
+	for _, n := range ns {
+		if n.Name.Local == local && (space == "" || n.Name.Space == space) {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// Value returns the trimmed text of the first element with the given namespace
+// and local name, or "" if there is none. An empty space matches any namespace.
+func (ns Nodes) Value(space, local string) string {
+	n, _ := ns.Find(space, local)
+	return n.Value()
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (n *Node) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*n = Node{Name: start.Name}
+	for _, a := range start.Attr {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		n.Attrs = append(n.Attrs, a)
+	}
+
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			var child Node
+			if err := child.UnmarshalXML(d, t); err != nil {
+				return err
+			}
+			n.Children = append(n.Children, child)
+		case xml.EndElement:
+			n.Text = text.String()
+			return nil
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package wxr
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const nodeXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/"
+	xmlns:atom="http://www.w3.org/2005/Atom"
+	xmlns:yoast="https://yoast.com/wxr/">
+<channel>
+	<title>Site</title>
+	<atom:link href="https://example.com/feed/" rel="self" type="application/rss+xml"/>
+	<wp:wxr_version>1.2</wp:wxr_version>
+	<item>
+		<title>Sticky</title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:comment_status><![CDATA[open]]></wp:comment_status>
+		<wp:ping_status><![CDATA[closed]]></wp:ping_status>
+		<wp:is_sticky>1</wp:is_sticky>
+		<wp:post_password><![CDATA[s3cret]]></wp:post_password>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<yoast:seo score="80">
+			<yoast:focus_keyword><![CDATA[go & wxr]]></yoast:focus_keyword>
+			<plain>unqualified</plain>
+		</yoast:seo>
+	</item>
+</channel>
+</rss>`
+
+func TestParseDocument_Extra(t *testing.T) {
+	doc, err := ParseDocument(context.Background(), strings.NewReader(nodeXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+
+	link, ok := doc.Site.Extra.Find("http://www.w3.org/2005/Atom", "link")
+	if !ok {
+		t.Fatalf("expected atom:link in Site.Extra, got %+v", doc.Site.Extra)
+	}
+	if href, _ := link.Attr("href"); href != "https://example.com/feed/" {
+		t.Errorf("atom:link href = %q", href)
+	}
+
+	if len(doc.Posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(doc.Posts))
+	}
+	extra := doc.Posts[0].Extra
+	for local, want := range map[string]string{
+		"comment_status": "open",
+		"ping_status":    "closed",
+		"is_sticky":      "1",
+		"post_password":  "s3cret",
+	} {
+		if got := extra.Value(wpNamespace, local); got != want {
+			t.Errorf("wp:%s = %q, want %q", local, got, want)
+		}
+	}
+
+	seo, ok := extra.Find("https://yoast.com/wxr/", "seo")
+	if !ok {
+		t.Fatalf("expected yoast:seo in Post.Extra, got %+v", extra)
+	}
+	if score, _ := seo.Attr("score"); score != "80" {
+		t.Errorf("yoast:seo score = %q, want %q", score, "80")
+	}
+	if got := seo.Children.Value("", "focus_keyword"); got != "go & wxr" {
+		t.Errorf("focus_keyword = %q, want %q", got, "go & wxr")
+	}
+	if plain, _ := seo.Children.Find("", "plain"); plain.Name.Space != "" {
+		t.Errorf("plain namespace = %q, want none", plain.Name.Space)
+	}
+	if _, ok := extra.Find("", "title"); ok {
+		t.Error("mapped elements should not be in Extra")
+	}
+}
+
+func TestEncoder_Extra(t *testing.T) {
+	doc, err := ParseDocument(context.Background(), strings.NewReader(nodeXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(doc); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "<wp:is_sticky><![CDATA[1]]></wp:is_sticky>") {
+		t.Errorf("expected wp:is_sticky with the wp prefix, got:\n%s", buf.String())
+	}
+
+	again, err := ParseDocument(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ParseDocument() of encoded document error = %v", err)
+	}
+	if !reflect.DeepEqual(doc.Site.Extra, again.Site.Extra) {
+		t.Errorf("Site.Extra changed:\nbefore: %+v\nafter:  %+v", doc.Site.Extra, again.Site.Extra)
+	}
+	if !reflect.DeepEqual(doc.Posts[0].Extra, again.Posts[0].Extra) {
+		t.Errorf("Post.Extra changed:\nbefore: %+v\nafter:  %+v", doc.Posts[0].Extra, again.Posts[0].Extra)
+	}
+}
+
+func TestItem_Extra(t *testing.T) {
+	sticky := FilterFunc(func(item Item) bool {
+		return item.Extra().Value(wpNamespace, "is_sticky") == "1"
+	})
+	posts, err := NewParser().WithFilter(sticky).Parse(strings.NewReader(nodeXML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(posts) != 1 {
+		t.Errorf("expected the sticky post, got %d posts", len(posts))
+	}
+}
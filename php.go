@@ -0,0 +1,361 @@
+package wxr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PHPObject is a PHP object decoded from serialize() output.
+type PHPObject struct {
+	// Class is the class name of the object, such as "stdClass".
+	Class string
+
+	// Properties are the object properties by name. Private and protected
+	// properties are included without their visibility prefix.
+	Properties map[string]any
+}
+
+// UnserializePHP decodes a value produced by PHP's serialize(), as found in
+// postmeta values such as _wp_attachment_metadata, ACF fields or plugin settings.
+// Strings become string, integers int64, floats float64, booleans bool and null nil.
+// Arrays whose keys are 0..n-1 in order become []any and other arrays map[string]any,
+// with integer keys written in decimal. Objects become PHPObject. Objects implementing
+// Serializable keep their class-specific data as a string, and references are nil.
+//
+// String lengths that do not match the data, as left behind by a search-replace
+// of URLs on the raw database, are repaired by locating the real end of the string.
+// Other corrupt data, such as an element count larger than the data, returns an error.
+func UnserializePHP(s string) (any, error) {
+	d := &phpDecoder{data: s}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, d.errorf("unexpected data after value")
+	}
+	return v, nil
+}
+
+// phpDecoder reads PHP serialized values from data.
+type phpDecoder struct {
+	data string
+	pos  int
+}
+
+func (d *phpDecoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("wxr: invalid PHP serialized data at offset %d: %s", d.pos, fmt.Sprintf(format, args...))
+}
+
+// value decodes the value starting at the current position.
+func (d *phpDecoder) value() (any, error) {
+	if d.pos >= len(d.data) {
+		return nil, d.errorf("unexpected end of data")
+	}
+	kind := d.data[d.pos]
+	if kind == 'N' {
+		d.pos++
+		return nil, d.expect(";")
+	}
+	d.pos++
+	if err := d.expect(":"); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 'b':
+		n, err := d.integer(';')
+		if err != nil {
+			return nil, err
+		}
+		return n != 0, nil
+	case 'i':
+		return d.integer(';')
+	case 'd':
+		return d.float()
+	case 's':
+		return d.str()
+	case 'E':
+		// PHP 8.1 enum case, serialized as "Class:Case"
+		return d.str()
+	case 'a':
+		return d.array()
+	case 'O':
+		return d.object()
+	case 'C':
+		return d.custom()
+	case 'r', 'R':
+		// References to values seen earlier are not resolved
+		if _, err := d.integer(';'); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	default:
+		d.pos--
+		return nil, d.errorf("unknown type %q", kind)
+	}
+}
+
+// expect consumes s or fails.
+func (d *phpDecoder) expect(s string) error {
+	if !strings.HasPrefix(d.data[d.pos:], s) {
+		return d.errorf("expected %q", s)
+	}
+	d.pos += len(s)
+	return nil
+}
+
+// until returns the text up to the next occurrence of end and consumes both.
+func (d *phpDecoder) until(end byte) (string, error) {
+	i := strings.IndexByte(d.data[d.pos:], end)
+	if i < 0 {
+		return "", d.errorf("expected %q", end)
+	}
+	s := d.data[d.pos : d.pos+i]
+	d.pos += i + 1
+	return s, nil
+}
+
+func (d *phpDecoder) integer(end byte) (int64, error) {
+	s, err := d.until(end)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, d.errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
+
+func (d *phpDecoder) float() (float64, error) {
+	s, err := d.until(';')
+	if err != nil {
+		return 0, err
+	}
+	switch s {
+	case "INF":
+		return math.Inf(1), nil
+	case "-INF":
+		return math.Inf(-1), nil
+	case "NAN":
+		return math.NaN(), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, d.errorf("invalid float %q", s)
+	}
+	return f, nil
+}
+
+// quoted reads a length-prefixed quoted string, N:"...", followed by term.
+// The length counts bytes; when it is wrong, the closing quote is searched for.
+func (d *phpDecoder) quoted(term byte) (string, error) {
+	n, err := d.integer(':')
+	if err != nil {
+		return "", err
+	}
+	if err := d.expect(`"`); err != nil {
+		return "", err
+	}
+	// A length beyond the data is searched for from the end, a negative one from
+	// the start; comparing with the bytes left cannot overflow
+	end := len(d.data)
+	switch {
+	case n < 0:
+		end = d.pos
+	case n <= int64(len(d.data)-d.pos):
+		end = d.pos + int(n)
+	}
+	if !d.closes(end, term) {
+		if end, err = d.findClose(end, term); err != nil {
+			return "", err
+		}
+	}
+	s := d.data[d.pos:end]
+	d.pos = end + 2
+	return s, nil
+}
+
+// closes reports whether a string can end at i: a closing quote followed by term
+// and by something that may follow a value.
+func (d *phpDecoder) closes(i int, term byte) bool {
+	if i < d.pos || i+1 >= len(d.data) || d.data[i] != '"' || d.data[i+1] != term {
+		return false
+	}
+	if term != ';' {
+		return true
+	}
+	rest := d.data[i+2:]
+	if rest == "" || rest[0] == '}' || strings.HasPrefix(rest, "N;") {
+		return true
+	}
+	return len(rest) >= 2 && rest[1] == ':' && strings.IndexByte("bidsaOCErR", rest[0]) >= 0
+}
+
+// findClose finds the end of a string whose declared length is wrong, choosing
+// the valid end closest to the declared one.
+func (d *phpDecoder) findClose(declared int, term byte) (int, error) {
+	best := -1
+	for i := d.pos; i+1 < len(d.data); i++ {
+		if !d.closes(i, term) {
+			continue
+		}
+		if best < 0 || abs(i-declared) < abs(best-declared) {
+			best = i
+		}
+		if i > declared {
+			break
+		}
+	}
+	if best < 0 {
+		return 0, d.errorf("unterminated string")
+	}
+	return best, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (d *phpDecoder) str() (string, error) {
+	return d.quoted(';')
+}
+
+// key decodes an array key, which is an integer or a string.
+func (d *phpDecoder) key() (string, error) {
+	v, err := d.value()
+	if err != nil {
+		return "", err
+	}
+	switch k := v.(type) {
+	case int64:
+		return strconv.FormatInt(k, 10), nil
+	case string:
+		return k, nil
+	default:
+		return "", d.errorf("invalid array key of type %T", v)
+	}
+}
+
+// members decodes the count:{key;value...} body shared by arrays and objects.
+func (d *phpDecoder) members() ([]string, map[string]any, error) {
+	n, err := d.integer(':')
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := d.expect("{"); err != nil {
+		return nil, nil, err
+	}
+	// Every member takes several bytes, so a count larger than the bytes left
+	// is corrupt; the count is not used to size allocations either way
+	if n < 0 || n > int64(len(d.data)-d.pos) {
+		return nil, nil, d.errorf("invalid element count %d", n)
+	}
+	var keys []string
+	values := make(map[string]any)
+	for i := int64(0); i < n; i++ {
+		k, err := d.key()
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, dup := values[k]; !dup {
+			keys = append(keys, k)
+		}
+		values[k] = v
+	}
+	return keys, values, d.expect("}")
+}
+
+func (d *phpDecoder) array() (any, error) {
+	keys, values, err := d.members()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]any, 0, len(keys))
+	for i, k := range keys {
+		if k != strconv.Itoa(i) {
+			return values, nil
+		}
+		list = append(list, values[k])
+	}
+	return list, nil
+}
+
+func (d *phpDecoder) object() (any, error) {
+	class, err := d.quoted(':')
+	if err != nil {
+		return nil, err
+	}
+	keys, values, err := d.members()
+	if err != nil {
+		return nil, err
+	}
+	properties := make(map[string]any, len(keys))
+	for _, k := range keys {
+		// Private and protected properties are prefixed with "\x00Class\x00" or "\x00*\x00"
+		name := k
+		if strings.HasPrefix(name, "\x00") {
+			if i := strings.IndexByte(name[1:], 0); i >= 0 {
+				name = name[i+2:]
+			}
+		}
+		properties[name] = values[k]
+	}
+	return PHPObject{Class: class, Properties: properties}, nil
+}
+
+// custom decodes an object implementing Serializable, C:N:"Class":N:{data}.
+// Its data is in a class-specific format and is returned as a string.
+func (d *phpDecoder) custom() (any, error) {
+	if _, err := d.quoted(':'); err != nil {
+		return nil, err
+	}
+	n, err := d.integer(':')
+	if err != nil {
+		return nil, err
+	}
+	if err := d.expect("{"); err != nil {
+		return nil, err
+	}
+	if n < 0 || n >= int64(len(d.data)-d.pos) || d.data[d.pos+int(n)] != '}' {
+		return nil, d.errorf("object data length %d does not match data", n)
+	}
+	s := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n) + 1
+	return s, nil
+}
+
+// IsPHPSerialized reports whether s looks like the output of PHP's serialize(),
+// using the same checks as WordPress's is_serialized().
+func IsPHPSerialized(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "N;" {
+		return true
+	}
+	if len(s) < 4 || s[1] != ':' {
+		return false
+	}
+	last := s[len(s)-1]
+	if last != ';' && last != '}' {
+		return false
+	}
+	switch s[0] {
+	case 's':
+		return s[len(s)-2] == '"'
+	case 'a', 'O', 'E', 'C':
+		return strings.IndexByte("0123456789", s[2]) >= 0
+	case 'b', 'i', 'd':
+		return last == ';'
+	}
+	return false
+}
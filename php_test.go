@@ -0,0 +1,207 @@
+package wxr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnserializePHP(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want any
+	}{
+		{"null", "N;", nil},
+		{"bool", "b:1;", true},
+		{"int", "i:-42;", int64(-42)},
+		{"float", "d:1.5;", 1.5},
+		{"string", `s:5:"hello";`, "hello"},
+		{"multibyte string", `s:10:"São João";`, "São João"},
+		{"string with quotes", `s:7:"a";s:"b";`, `a";s:"b`},
+		{"list", `a:2:{i:0;s:1:"a";i:1;s:1:"b";}`, []any{"a", "b"}},
+		{"map", `a:2:{s:5:"width";i:10;i:5;b:0;}`, map[string]any{"width": int64(10), "5": false}},
+		{"empty array", "a:0:{}", []any{}},
+		{
+			"object",
+			`O:8:"stdClass":2:{s:4:"name";s:3:"Ana";s:6:"` + "\x00*\x00" + `age";i:30;}`,
+			PHPObject{Class: "stdClass", Properties: map[string]any{"name": "Ana", "age": int64(30)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnserializePHP(tt.in)
+			if err != nil {
+				t.Fatalf("UnserializePHP(%q) error = %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnserializePHP(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnserializePHP_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"", "x:1;", `s:5:"short`, "a:2:{i:0;i:1;}", "i:1;extra",
+		// Counts and lengths that do not fit the data
+		"a:-1:{}", "a:99999999999999:{}", "a:9223372036854775807:{}",
+		`O:8:"stdClass":-3:{}`, `O:8:"stdClass":99999999999999:{}`,
+		`C:1:"X":9223372036854775807:{}`, `C:1:"X":-1:{}`, `C:1:"X":5:{}`,
+	} {
+		if _, err := UnserializePHP(in); err == nil {
+			t.Errorf("UnserializePHP(%q) expected error", in)
+		}
+	}
+}
+
+func TestUnserializePHP_CorruptedLengths(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want any
+	}{
+		{
+			// http://old.example.com (22 bytes) replaced by https://example.com (19 bytes)
+			"shorter after search-replace",
+			`a:2:{s:3:"url";s:22:"https://example.com";s:5:"title";s:4:"Home";}`,
+			map[string]any{"url": "https://example.com", "title": "Home"},
+		},
+		{
+			"longer after search-replace",
+			`a:2:{s:3:"url";s:10:"https://www.example.com/page";i:0;b:1;}`,
+			map[string]any{"url": "https://www.example.com/page", "0": true},
+		},
+		{
+			"characters counted instead of bytes",
+			`a:1:{s:4:"city";s:9:"São Paulo";}`,
+			map[string]any{"city": "São Paulo"},
+		},
+		{
+			"last value",
+			`s:3:"https://example.com";`,
+			"https://example.com",
+		},
+		{
+			"string containing a quote and semicolon",
+			`a:2:{i:0;s:4:"say "hi"; now";i:1;s:1:"x";}`,
+			[]any{`say "hi"; now`, "x"},
+		},
+		{
+			"length past the end of the data",
+			`s:9223372036854775807:"abc";`,
+			"abc",
+		},
+		{
+			"negative length",
+			`a:1:{i:0;s:-2:"abc";}`,
+			[]any{"abc"},
+		},
+		{
+			"object class name",
+			`O:3:"stdClass":1:{s:1:"a";i:1;}`,
+			PHPObject{Class: "stdClass", Properties: map[string]any{"a": int64(1)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnserializePHP(tt.in)
+			if err != nil {
+				t.Fatalf("UnserializePHP(%q) error = %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnserializePHP(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPHPSerialized(t *testing.T) {
+	tests := map[string]bool{
+		"N;":                  true,
+		"b:0;":                true,
+		"i:12;":               true,
+		"d:0.5;":              true,
+		`s:2:"ab";`:           true,
+		"a:0:{}":              true,
+		`O:8:"stdClass":0:{}`: true,
+		"":                    false,
+		"hello":               false,
+		"a:b":                 false,
+		"12":                  false,
+		`s:2:"ab"`:            false,
+	}
+	for in, want := range tests {
+		if got := IsPHPSerialized(in); got != want {
+			t.Errorf("IsPHPSerialized(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestPost_MetaValue(t *testing.T) {
+	xmlData := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title><![CDATA[Product]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[_product_attributes]]></wp:meta_key>
+			<wp:meta_value><![CDATA[a:1:{s:5:"color";a:2:{s:4:"name";s:5:"Color";s:5:"value";s:10:"Red | Blue";}}]]></wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[gallery]]></wp:meta_key>
+			<wp:meta_value><![CDATA[a:2:{i:0;s:2:"12";i:1;s:2:"13";}]]></wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[negative]]></wp:meta_key>
+			<wp:meta_value><![CDATA[a:-1:{}]]></wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[huge]]></wp:meta_key>
+			<wp:meta_value><![CDATA[a:99999999999999:{}]]></wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[overflow]]></wp:meta_key>
+			<wp:meta_value><![CDATA[C:1:"X":9223372036854775807:{}]]></wp:meta_value>
+		</wp:postmeta>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[subtitle]]></wp:meta_key>
+			<wp:meta_value><![CDATA[Plain text]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+</channel>
+</rss>`
+
+	posts, err := Parse(strings.NewReader(xmlData))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	post := posts[0]
+
+	attrs, ok := post.MetaValue("_product_attributes")
+	want := map[string]any{"color": map[string]any{"name": "Color", "value": "Red | Blue"}}
+	if !ok || !reflect.DeepEqual(attrs, want) {
+		t.Errorf("MetaValue(_product_attributes) = %#v, %v", attrs, ok)
+	}
+	if gallery, _ := post.MetaValue("gallery"); !reflect.DeepEqual(gallery, []any{"12", "13"}) {
+		t.Errorf("MetaValue(gallery) = %#v", gallery)
+	}
+	if subtitle, _ := post.MetaValue("subtitle"); subtitle != "Plain text" {
+		t.Errorf("MetaValue(subtitle) = %#v", subtitle)
+	}
+	for _, key := range []string{"negative", "huge", "overflow"} {
+		// Corrupt counts fall back to the raw string
+		if v, ok := post.MetaValue(key); !ok || v != post.Meta[key] {
+			t.Errorf("MetaValue(%s) = %#v, %v, want the raw value", key, v, ok)
+		}
+	}
+	if v, ok := post.MetaValue("missing"); ok || v != nil {
+		t.Errorf("MetaValue(missing) = %#v, %v", v, ok)
+	}
+}
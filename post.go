@@ -9,7 +9,14 @@ type Post struct {
 	// TitleRendered is the post title (HTML may be present).
 	TitleRendered string
 
-	// ContentRendered is the full post content (HTML).
+	// TitlePlain is TitleRendered as plain text, with tags removed and character
+	// references decoded. It is set only when the parser is configured with
+	// WithPlainText.
+	TitlePlain string
+
+	// ContentRendered is the full post content (HTML), as stored by WordPress.
+	// Classic editor content has line breaks instead of paragraph tags unless
+	// the parser is configured with WithAutoP.
 	ContentRendered string
 
 	// Slug is the URL-friendly post slug.
@@ -18,24 +25,61 @@ type Post struct {
 	// Link is the canonical permalink URL for the post.
 	Link string
 
+	// Type is the post type, such as "post", "page" or a custom post type like "product".
+	Type string
+
+	// Status is the post status, such as "publish", "draft" or "private".
+	Status string
+
+	// ContentRaw is the content as exported (content:encoded), before WithAutoP.
+	ContentRaw string
+
 	// Excerpt is the post excerpt or summary.
 	Excerpt string
 
-	// Author is the post author name.
+	// ExcerptRaw is the excerpt as exported (excerpt:encoded), without the
+	// fallback to a meta field that Excerpt may come from.
+	ExcerptRaw string
+
+	// RSSDescription is the description element of the item. WordPress exports
+	// it empty; other tools that write WXR may fill it with a summary.
+	RSSDescription string
+
+	// Author is the post author name. It comes from an explicit meta field when
+	// present, otherwise from the author record matching AuthorLogin.
 	Author string
 
+	// AuthorLogin is the login of the post author (dc:creator).
+	AuthorLogin string
+
 	// Categories is a list of category names associated with the post.
 	Categories []string
 
+	// CategoriesPlain holds the names of Categories as plain text, in the same
+	// order. It is set only when the parser is configured with WithPlainText.
+	CategoriesPlain []string
+
 	// Tags is a list of tag names associated with the post.
 	Tags []string
 
+	// Terms are all terms assigned to the post keyed by taxonomy ("category", "post_tag",
+	// "post_format", "product_cat", ...). Each term has the display name and slug
+	// (nicename) from the item, plus its ID and parent when the export declares them.
+	Terms map[string][]Term
+
 	// Date is the post publication date in RFC3339 format.
 	Date string
 
+	// LocalDate is the publication date in the site's time zone (wp:post_date), as exported.
+	LocalDate string
+
 	// ModifiedDate is the post last modification date in RFC3339 format.
 	ModifiedDate string
 
+	// LocalModifiedDate is the modification date in the site's time zone
+	// (wp:post_modified), as exported.
+	LocalModifiedDate string
+
 	// FeaturedImage is the URL of the featured image for the post.
 	FeaturedImage string
 
@@ -45,6 +89,50 @@ type Post struct {
 	// ParentID is the ID of the parent post (for hierarchical post types like pages).
 	ParentID int
 
+	// MenuOrder is the position of the post among its siblings (wp:menu_order),
+	// used by WordPress to order pages.
+	MenuOrder int
+
+	// PageTemplate is the template file assigned to the post (_wp_page_template meta),
+	// or "default". It is empty when no template was set.
+	PageTemplate string
+
 	// Meta contains all post meta fields as key-value pairs.
 	Meta map[string]string
+
+	// MetaEntries are the meta fields as exported, in order, including repeated
+	// keys such as _wp_old_slug and empty values that Meta leaves out.
+	MetaEntries []MetaEntry
+
+	// Comments are the comments, pingbacks and trackbacks on the post, in export order.
+	// Use CommentTree to arrange them into reply threads.
+	Comments []Comment
+
+	// Extra are the item elements the package does not map, such as
+	// wp:comment_status, wp:ping_status, wp:is_sticky, wp:post_password or
+	// elements added by plugins, in document order.
+	Extra Nodes
+}
+
+// CommentTree arranges the post's comments into reply threads.
+// See BuildCommentTree.
+func (p Post) CommentTree() []*CommentNode {
+	return BuildCommentTree(p.Comments)
+}
+
+// MetaValue returns the meta field with the given key, decoding PHP-serialized
+// values (arrays, objects, numbers) with UnserializePHP. Other values, and
+// serialized values that cannot be decoded, are returned as strings.
+// It reports false if the post has no such meta field.
+func (p Post) MetaValue(key string) (any, bool) {
+	raw, ok := p.Meta[key]
+	if !ok {
+		return nil, false
+	}
+	if IsPHPSerialized(raw) {
+		if v, err := UnserializePHP(raw); err == nil {
+			return v, true
+		}
+	}
+	return raw, true
 }
@@ -0,0 +1,445 @@
+package wxr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// DiagnosticAction says what the parser did about a problem in the input.
+type DiagnosticAction int
+
+const (
+	// DiagnosticRepaired means the data was fixed and kept.
+	DiagnosticRepaired DiagnosticAction = iota
+
+	// DiagnosticDropped means data was lost: an item, or channel-level elements.
+	DiagnosticDropped
+)
+
+func (a DiagnosticAction) String() string {
+	if a == DiagnosticDropped {
+		return "dropped"
+	}
+	return "repaired"
+}
+
+// Diagnostic describes a problem the parser worked around in recovery mode or
+// by mojibake repair. See Parser.WithRecovery and Parser.WithMojibakeRepair.
+type Diagnostic struct {
+	// Action says whether the data was repaired or dropped.
+	Action DiagnosticAction
+
+	// Item is the 1-based position of the affected item among the items of the
+	// document, or 0 for problems outside items.
+	Item int
+
+	// PostID is the wp:post_id of the affected item, or 0 if it was not read.
+	PostID int
+
+	// Line and Column are the 1-based position of the problem, or 0. Positions
+	// count the input after characters not allowed in XML were removed.
+	Line   int
+	Column int
+
+	// Message describes the problem.
+	Message string
+
+	// Source names the file of a merged export the problem is in, or is empty.
+	// See Parser.ParseMerged.
+	Source string
+}
+
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if d.Source != "" {
+		b.WriteString(d.Source + ": ")
+	}
+	b.WriteString(d.Action.String())
+	if d.Item > 0 {
+		fmt.Fprintf(&b, " item %d", d.Item)
+		if d.PostID != 0 {
+			fmt.Fprintf(&b, " (post %d)", d.PostID)
+		}
+	}
+	if d.Line > 0 {
+		fmt.Fprintf(&b, " at line %d, column %d", d.Line, d.Column)
+	}
+	b.WriteString(": ")
+	b.WriteString(d.Message)
+	return b.String()
+}
+
+// inputPos is a position in the input.
+type inputPos struct {
+	line, column int
+	offset       int64
+}
+
+// advance returns the position after b.
+func (p inputPos) advance(b []byte) inputPos {
+	for _, c := range b {
+		if c == '\n' {
+			p.line++
+			p.column = 1
+		} else {
+			p.column++
+		}
+	}
+	p.offset += int64(len(b))
+	return p
+}
+
+// recordedToken is a token read while decoding an item in recovery mode.
+type recordedToken struct {
+	token        xml.Token
+	line, column int
+}
+
+// maxItemRepairs limits the elements dropped from a single item before the
+// item itself is dropped.
+const maxItemRepairs = 8
+
+// recover works around err in recovery mode and reports whether reading can
+// continue. Truncated documents end the reading; other syntax errors skip to
+// the next <item>, and item errors have already been handled by repairItem.
+func (ir *itemReader) recover(err error) bool {
+	var syntaxErr *SyntaxError
+	if !ir.started || !errors.As(err, &syntaxErr) {
+		return false
+	}
+	diagnostic := Diagnostic{Action: DiagnosticDropped, Line: syntaxErr.Line, Column: syntaxErr.Column}
+	if ir.inItem {
+		diagnostic.Item, diagnostic.PostID = ir.index, ir.itemID
+	}
+	ir.inItem = false
+
+	if ir.input.eof && strings.HasPrefix(syntaxErr.Msg, "unexpected EOF") {
+		switch {
+		case diagnostic.Item > 0:
+			diagnostic.Message = "item is truncated at the end of the document"
+		case ir.source.depth > 2:
+			diagnostic.Message = "channel element is truncated at the end of the document"
+		default:
+			diagnostic.Action = DiagnosticRepaired
+			diagnostic.Message = "document ends without its closing tags"
+		}
+		ir.diagnose(diagnostic)
+		ir.done = true
+		return true
+	}
+
+	if diagnostic.Item > 0 {
+		diagnostic.Message = "broken XML in item: " + syntaxErr.Msg
+	} else {
+		diagnostic.Message = "broken XML in channel, skipped to the next item: " + syntaxErr.Msg
+	}
+	ir.diagnose(diagnostic)
+	ir.restart(syntaxErr.Offset)
+	return true
+}
+
+// restart resumes decoding at the first <item> after offset, with a synthetic
+// root and channel that declare the namespaces of the document.
+func (ir *itemReader) restart(offset int64) {
+	var prefix strings.Builder
+	prefix.WriteString("<rss")
+	for _, a := range ir.root.Attr {
+		switch {
+		case a.Name.Space == "xmlns":
+			fmt.Fprintf(&prefix, " xmlns:%s=\"%s\"", a.Name.Local, escapeText(a.Value))
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			fmt.Fprintf(&prefix, " xmlns=\"%s\"", escapeText(a.Value))
+		}
+	}
+	prefix.WriteString("><channel>")
+
+	base, ok := ir.input.resume(offset, prefix.String())
+	if !ok {
+		ir.done = true
+		return
+	}
+	ir.open(ir.input, base, prefix.Len())
+	for range 2 {
+		// The synthetic start tags are well-formed
+		_, _ = ir.decoder.Token()
+	}
+}
+
+// repairItem decodes the recorded tokens of an item again without the element
+// that failed, for as long as the failing element is known.
+func (ir *itemReader) repairItem(field elementPos, err error) (*item, error) {
+	tokens := ir.source.tokens
+	for range maxItemRepairs {
+		if field.index <= 0 {
+			break
+		}
+		ir.diagnose(Diagnostic{
+			Action:  DiagnosticRepaired,
+			Item:    ir.index,
+			PostID:  ir.itemID,
+			Line:    field.line,
+			Column:  field.column,
+			Message: fmt.Sprintf("removed %s that could not be decoded: %v", qualifiedName(field.name), err),
+		})
+		tokens = withoutElement(tokens, field.index)
+
+		src := &tokenSlice{tokens: tokens}
+		var it item
+		if err = xml.NewTokenDecoder(src).Decode(&it); err == nil {
+			return &it, nil
+		}
+		ir.itemID = it.PostID
+		field = src.closed
+	}
+
+	ir.diagnose(Diagnostic{
+		Action:  DiagnosticDropped,
+		Item:    ir.index,
+		PostID:  ir.itemID,
+		Line:    field.line,
+		Column:  field.column,
+		Message: fmt.Sprintf("item could not be decoded: %v", err),
+	})
+	return ir.advance()
+}
+
+// diagnose records d.
+func (ir *itemReader) diagnose(d Diagnostic) {
+	ir.channel.Diagnostics = append(ir.channel.Diagnostics, d)
+}
+
+// withoutElement returns a copy of tokens without the element that starts at
+// index start.
+func withoutElement(tokens []recordedToken, start int) []recordedToken {
+	end, depth := start, 0
+	for ; end < len(tokens); end++ {
+		switch tokens[end].token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	out := make([]recordedToken, 0, len(tokens))
+	out = append(out, tokens[:start]...)
+	if end < len(tokens) {
+		out = append(out, tokens[end+1:]...)
+	}
+	return out
+}
+
+// tokenSlice replays recorded tokens, tracking the last element closed like
+// tokenSource.
+type tokenSlice struct {
+	tokens []recordedToken
+	next   int
+	open   []elementPos
+	closed elementPos
+}
+
+func (ts *tokenSlice) Token() (xml.Token, error) {
+	if ts.next >= len(ts.tokens) {
+		return nil, io.EOF
+	}
+	rec := ts.tokens[ts.next]
+	switch t := rec.token.(type) {
+	case xml.StartElement:
+		ts.open = append(ts.open, elementPos{name: t.Name, line: rec.line, column: rec.column, index: ts.next})
+	case xml.EndElement:
+		if n := len(ts.open); n > 0 {
+			ts.closed = ts.open[n-1]
+			ts.open = ts.open[:n-1]
+		}
+	}
+	ts.next++
+	return xml.CopyToken(rec.token), nil
+}
+
+// recoveryReader reads the input of a document in recovery mode. It removes the
+// control characters XML 1.0 does not allow, replaces invalid UTF-8 with
+// U+FFFD, and keeps the bytes read since the last mark so that decoding can
+// resume after a syntax error.
+type recoveryReader struct {
+	r       *bufio.Reader
+	prefix  []byte // synthetic bytes read before the input
+	replay  []byte // input bytes read again after a restart
+	pending []byte // rest of a replacement character
+	valid   int    // continuation bytes left in a validated UTF-8 sequence
+	eof     bool
+
+	pos      inputPos // position of the next input byte
+	marked   inputPos // position of recorded[0]
+	recorded []byte
+
+	removed   int // characters removed or replaced
+	firstBad  inputPos
+	reportedN int
+}
+
+func newRecoveryReader(r io.Reader) *recoveryReader {
+	start := inputPos{line: 1, column: 1}
+	return &recoveryReader{r: bufio.NewReader(r), pos: start, marked: start}
+}
+
+// Read implements io.Reader; the XML decoder reads through ReadByte, which does
+// not read ahead.
+func (rr *recoveryReader) Read(p []byte) (int, error) {
+	for i := range p {
+		b, err := rr.ReadByte()
+		if err != nil {
+			if i > 0 {
+				return i, nil
+			}
+			return 0, err
+		}
+		p[i] = b
+	}
+	return len(p), nil
+}
+
+func (rr *recoveryReader) ReadByte() (byte, error) {
+	if len(rr.prefix) > 0 {
+		b := rr.prefix[0]
+		rr.prefix = rr.prefix[1:]
+		return b, nil
+	}
+	var b byte
+	if len(rr.replay) > 0 {
+		b = rr.replay[0]
+		rr.replay = rr.replay[1:]
+	} else {
+		var err error
+		if b, err = rr.sanitized(); err != nil {
+			return 0, err
+		}
+	}
+	rr.recorded = append(rr.recorded, b)
+	rr.pos = rr.pos.advance([]byte{b})
+	return b, nil
+}
+
+// sanitized returns the next byte of the underlying input, removing control
+// characters and replacing invalid UTF-8.
+func (rr *recoveryReader) sanitized() (byte, error) {
+	for {
+		if len(rr.pending) > 0 {
+			b := rr.pending[0]
+			rr.pending = rr.pending[1:]
+			return b, nil
+		}
+		b, err := rr.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				rr.eof = true
+			}
+			return 0, err
+		}
+		if rr.valid > 0 {
+			rr.valid--
+			return b, nil
+		}
+
+		switch {
+		case b < 0x20 && b != '\t' && b != '\n' && b != '\r':
+			rr.bad()
+			continue
+		case b >= utf8.RuneSelf:
+			next, _ := rr.r.Peek(utf8.UTFMax - 1)
+			r, size := utf8.DecodeRune(append([]byte{b}, next...))
+			if (r == utf8.RuneError && size <= 1) || r == 0xFFFE || r == 0xFFFF {
+				rr.bad()
+				if size > 1 {
+					_, _ = rr.r.Discard(size - 1)
+				}
+				rr.pending = []byte(string(utf8.RuneError))
+				continue
+			}
+			rr.valid = size - 1
+		}
+		return b, nil
+	}
+}
+
+// bad counts a character that was removed or replaced.
+func (rr *recoveryReader) bad() {
+	if rr.removed == 0 {
+		rr.firstBad = rr.pos
+	}
+	rr.removed++
+}
+
+// mark discards the recorded bytes; decoding can resume from the next byte on.
+func (rr *recoveryReader) mark() {
+	rr.recorded = rr.recorded[:0]
+	rr.marked = rr.pos
+}
+
+// resume prepares to read the input again from the first <item> at or after
+// offset, preceded by prefix. The decoder may already have read the '<' of the
+// tag it stopped at, so the search starts one byte before offset. It returns
+// the position of that <item>, or false if the input has no more items.
+func (rr *recoveryReader) resume(offset int64, prefix string) (inputPos, bool) {
+	skip := min(max(offset-1-rr.marked.offset, 0), int64(len(rr.recorded)))
+	start := rr.marked.advance(rr.recorded[:skip])
+	data := append(append([]byte(nil), rr.recorded[skip:]...), rr.replay...)
+	rr.replay = nil
+
+	for searched := 0; ; {
+		if i := itemStart(data, searched); i >= 0 {
+			start = start.advance(data[:i])
+			rr.replay = data[i:]
+			rr.prefix = []byte(prefix)
+			rr.pos, rr.marked = start, start
+			rr.recorded = rr.recorded[:0]
+			return start, true
+		}
+		searched = max(len(data)-len("<item"), 0)
+		b, err := rr.sanitized()
+		if err != nil {
+			return inputPos{}, false
+		}
+		data = append(data, b)
+	}
+}
+
+// itemStart returns the index of the first <item> start tag in data at or
+// after from, or -1.
+func itemStart(data []byte, from int) int {
+	for from < len(data) {
+		i := bytes.Index(data[from:], []byte("<item"))
+		if i < 0 || from+i+len("<item") >= len(data) {
+			return -1
+		}
+		i += from
+		switch data[i+len("<item")] {
+		case '>', '/', ' ', '\t', '\n', '\r':
+			return i
+		}
+		from = i + 1
+	}
+	return -1
+}
+
+// report records a diagnostic for the characters removed or replaced since the
+// last report.
+func (rr *recoveryReader) report(ch *channel) {
+	if rr.removed == rr.reportedN {
+		return
+	}
+	ch.Diagnostics = append(ch.Diagnostics, Diagnostic{
+		Action:  DiagnosticRepaired,
+		Line:    rr.firstBad.line,
+		Column:  rr.firstBad.column,
+		Message: fmt.Sprintf("removed or replaced %d characters not allowed in XML", rr.removed-rr.reportedN),
+	})
+	rr.reportedN = rr.removed
+}
@@ -0,0 +1,147 @@
+package wxr
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const recoveryHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Recovered</title>
+`
+
+// recoveryItem returns a published post item.
+func recoveryItem(id, title string) string {
+	return `	<item>
+		<title>` + title + `</title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>` + id + `</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+`
+}
+
+func TestParser_WithRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		xml       string
+		wantIDs   []int
+		wantTitle map[int]string
+		want      []Diagnostic
+	}{
+		{
+			name: "illegal characters",
+			xml: recoveryHeader + recoveryItem("1", "Back\x08space") + recoveryItem("2", "Caf\xe9 & bar") +
+				"</channel>\n</rss>",
+			wantIDs:   []int{1, 2},
+			wantTitle: map[int]string{1: "Backspace", 2: "Caf� & bar"},
+			want: []Diagnostic{
+				{Action: DiagnosticRepaired, Line: 8, Column: 14, Message: "removed or replaced 2 characters not allowed in XML"},
+			},
+		},
+		{
+			name: "broken item is skipped",
+			xml: recoveryHeader + recoveryItem("1", "One") + recoveryItem("2", "Two</title") + recoveryItem("3", "Three") +
+				recoveryItem("4", "Four</title") + recoveryItem("5", "Five") + "</channel>\n</rss>",
+			wantIDs: []int{1, 3, 5},
+			want: []Diagnostic{
+				{Action: DiagnosticDropped, Item: 2, Line: 15, Column: 21, Message: "broken XML in item: invalid characters between </title and >"},
+				{Action: DiagnosticDropped, Item: 4, Line: 29, Column: 22, Message: "broken XML in item: invalid characters between </title and >"},
+			},
+		},
+		{
+			name: "invalid field is removed",
+			xml: recoveryHeader + strings.Replace(recoveryItem("1", "One"), "<wp:status>", "<wp:menu_order>first</wp:menu_order><wp:status>", 1) +
+				recoveryItem("x", "No ID") + recoveryItem("3", "Three") + "</channel>\n</rss>",
+			wantIDs: []int{1, 3},
+			want: []Diagnostic{
+				{Action: DiagnosticRepaired, Item: 1, PostID: 1, Line: 12, Column: 3, Message: `removed wp:menu_order that could not be decoded: strconv.ParseInt: parsing "first": invalid syntax`},
+				{Action: DiagnosticRepaired, Item: 2, Line: 17, Column: 3, Message: `removed wp:post_id that could not be decoded: strconv.ParseInt: parsing "x": invalid syntax`},
+			},
+		},
+		{
+			name:    "broken channel element",
+			xml:     recoveryHeader + "\t<wp:wxr_version>1.2</wp:wxr_version\n" + recoveryItem("1", "One") + "</channel>\n</rss>",
+			wantIDs: []int{1},
+			want: []Diagnostic{
+				{Action: DiagnosticDropped, Line: 8, Column: 3, Message: "broken XML in channel, skipped to the next item: invalid characters between </wxr_version and >"},
+			},
+		},
+		{
+			name:    "truncated in an item",
+			xml:     recoveryHeader + recoveryItem("1", "One") + recoveryItem("2", "Two")[:60],
+			wantIDs: []int{1},
+			want: []Diagnostic{
+				{Action: DiagnosticDropped, Item: 2, Line: 16, Column: 32, Message: "item is truncated at the end of the document"},
+			},
+		},
+		{
+			name:    "missing closing tags",
+			xml:     recoveryHeader + recoveryItem("1", "One") + recoveryItem("2", "Two"),
+			wantIDs: []int{1, 2},
+			want: []Diagnostic{
+				{Action: DiagnosticRepaired, Line: 21, Column: 1, Message: "document ends without its closing tags"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.xml)); err == nil {
+				t.Error("expected Parse() to fail without recovery")
+			}
+
+			doc, err := NewParser().WithRecovery(true).ParseDocument(context.Background(), strings.NewReader(tt.xml))
+			if err != nil {
+				t.Fatalf("ParseDocument() error = %v", err)
+			}
+			var ids []int
+			for _, post := range doc.Posts {
+				ids = append(ids, post.ID)
+				if want, ok := tt.wantTitle[post.ID]; ok && post.TitleRendered != want {
+					t.Errorf("post %d title = %q, want %q", post.ID, post.TitleRendered, want)
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("post IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			if len(doc.Diagnostics) != len(tt.want) {
+				t.Fatalf("Diagnostics = %v, want %v", doc.Diagnostics, tt.want)
+			}
+			for i, d := range doc.Diagnostics {
+				if d != tt.want[i] {
+					t.Errorf("Diagnostics[%d] = %+v, want %+v", i, d, tt.want[i])
+				}
+			}
+
+			stream := NewParser().WithRecovery(true).NewStream(strings.NewReader(tt.xml))
+			ids = nil
+			for {
+				post, err := stream.Next()
+				if err != nil {
+					break
+				}
+				ids = append(ids, post.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("stream post IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			if len(stream.Diagnostics()) != len(tt.want) {
+				t.Errorf("stream Diagnostics = %v, want %v", stream.Diagnostics(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	d := Diagnostic{Action: DiagnosticDropped, Item: 4, PostID: 17, Line: 30, Column: 2, Message: "item is truncated"}
+	if want := "dropped item 4 (post 17) at line 30, column 2: item is truncated"; d.String() != want {
+		t.Errorf("String() = %q, want %q", d.String(), want)
+	}
+}
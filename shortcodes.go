@@ -0,0 +1,671 @@
+package wxr
+
+import (
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shortcode is a shortcode found in post content, such as [gallery ids="1,2"]
+// or [caption]...[/caption].
+type Shortcode struct {
+	// Tag is the shortcode name.
+	Tag string
+
+	// Attrs are the named attributes, with lowercase names.
+	Attrs map[string]string
+
+	// Args are the positional attributes, such as "https://example.com" in
+	// [embed "https://example.com"].
+	Args []string
+
+	// Content is the text between the opening and closing tags, unprocessed.
+	Content string
+
+	// Closed reports whether the shortcode has a closing tag ([/tag]).
+	Closed bool
+
+	// SelfClosing reports whether the shortcode was written as [tag /].
+	SelfClosing bool
+
+	// Escaped reports whether the shortcode was escaped with double brackets
+	// ([[tag]]). Escaped shortcodes are not expanded and lose one pair of brackets.
+	Escaped bool
+
+	// Raw is the shortcode as written in the content. A single extra bracket on
+	// either side, as in [[tag] or [tag]], is not part of it and is kept when the
+	// shortcode is expanded.
+	Raw string
+
+	// Start and End are the byte offsets of Raw in the content.
+	Start, End int
+
+	registry *ShortcodeRegistry
+}
+
+// Attr returns the named attribute, or def if the shortcode does not set it.
+func (s Shortcode) Attr(name, def string) string {
+	if v, ok := s.Attrs[strings.ToLower(name)]; ok {
+		return v
+	}
+	return def
+}
+
+// ExpandContent returns the content of the shortcode with the shortcodes it
+// contains expanded by the registry that is expanding this shortcode.
+// WordPress does not expand nested shortcodes unless the handler asks for it,
+// and neither does the registry.
+func (s Shortcode) ExpandContent() string {
+	return s.expand(s.Content)
+}
+
+// expand expands the shortcodes in text with the registry expanding s.
+func (s Shortcode) expand(text string) string {
+	if s.registry == nil {
+		return text
+	}
+	return s.registry.Expand(text)
+}
+
+// ShortcodeHandler renders a shortcode; the result replaces it in the content.
+type ShortcodeHandler func(sc Shortcode) string
+
+// StripShortcode is a ShortcodeHandler that removes the shortcode and its content.
+func StripShortcode(sc Shortcode) string {
+	return ""
+}
+
+// UnwrapShortcode is a ShortcodeHandler that replaces the shortcode with its
+// expanded content.
+func UnwrapShortcode(sc Shortcode) string {
+	return sc.ExpandContent()
+}
+
+// KeepShortcode is a ShortcodeHandler that leaves the shortcode as written.
+func KeepShortcode(sc Shortcode) string {
+	return sc.Raw
+}
+
+// ShortcodeRegistry maps shortcode tags to handlers and expands them in content,
+// following the parsing rules of WordPress's do_shortcode(). Only registered tags
+// are recognized, as in WordPress.
+type ShortcodeRegistry struct {
+	handlers    map[string]ShortcodeHandler
+	attachments map[int]Attachment
+}
+
+// NewShortcodeRegistry creates a registry with handlers for the core WordPress
+// shortcodes: caption, wp_caption, gallery, embed, video and audio.
+// The gallery handler needs attachments to render images; see WithAttachments.
+func NewShortcodeRegistry() *ShortcodeRegistry {
+	r := NewEmptyShortcodeRegistry()
+	r.Register("caption", captionShortcode)
+	r.Register("wp_caption", captionShortcode)
+	r.Register("gallery", r.galleryShortcode)
+	r.Register("embed", embedShortcode)
+	r.Register("video", videoShortcode)
+	r.Register("audio", audioShortcode)
+	return r
+}
+
+// NewEmptyShortcodeRegistry creates a registry without any handler.
+func NewEmptyShortcodeRegistry() *ShortcodeRegistry {
+	return &ShortcodeRegistry{
+		handlers:    make(map[string]ShortcodeHandler),
+		attachments: make(map[int]Attachment),
+	}
+}
+
+// Register sets the handler for a shortcode tag, replacing any existing one.
+// A nil handler removes the tag.
+// Returns the registry for method chaining.
+func (r *ShortcodeRegistry) Register(tag string, handler ShortcodeHandler) *ShortcodeRegistry {
+	if handler == nil {
+		delete(r.handlers, tag)
+	} else {
+		r.handlers[tag] = handler
+	}
+	return r
+}
+
+// WithAttachments makes attachments available to the built-in gallery handler,
+// typically Document.Attachments.
+// Returns the registry for method chaining.
+func (r *ShortcodeRegistry) WithAttachments(attachments []Attachment) *ShortcodeRegistry {
+	for _, a := range attachments {
+		r.attachments[a.ID] = a
+	}
+	return r
+}
+
+// Tags returns the registered shortcode tags in sorted order.
+func (r *ShortcodeRegistry) Tags() []string {
+	tags := make([]string, 0, len(r.handlers))
+	for tag := range r.handlers {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Parse returns the registered shortcodes found in content, in order.
+// Shortcodes nested in the content of another shortcode are not included;
+// parse Shortcode.Content to find them.
+func (r *ShortcodeRegistry) Parse(content string) []Shortcode {
+	var shortcodes []Shortcode
+	r.scan(content, func(sc Shortcode) {
+		shortcodes = append(shortcodes, sc)
+	})
+	return shortcodes
+}
+
+// Expand replaces each registered shortcode in content with the output of its
+// handler. Escaped shortcodes ([[tag]]) are unescaped instead.
+func (r *ShortcodeRegistry) Expand(content string) string {
+	return r.replace(content, func(sc Shortcode) string {
+		return r.handlers[sc.Tag](sc)
+	})
+}
+
+// Strip removes every registered shortcode and its content, as WordPress's
+// strip_shortcodes() does. Escaped shortcodes are unescaped instead.
+func (r *ShortcodeRegistry) Strip(content string) string {
+	return r.replace(content, func(sc Shortcode) string {
+		return ""
+	})
+}
+
+// replace rebuilds content with each shortcode replaced by render.
+func (r *ShortcodeRegistry) replace(content string, render func(Shortcode) string) string {
+	if !strings.Contains(content, "[") || len(r.handlers) == 0 {
+		return content
+	}
+	var b strings.Builder
+	last := 0
+	r.scan(content, func(sc Shortcode) {
+		b.WriteString(content[last:sc.Start])
+		if sc.Escaped {
+			b.WriteString(sc.Raw[1 : len(sc.Raw)-1])
+		} else {
+			b.WriteString(render(sc))
+		}
+		last = sc.End
+	})
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// scan calls fn for each registered shortcode in content, following the
+// expression built by WordPress's get_shortcode_regex():
+//
+//	\[(\[?)(tag)(?![\w-])([^\]\/]*(?:\/(?!\])[^\]\/]*)*?)(?:(\/)\]|\](?:(...)\[\/\2\])?)(\]?)
+func (r *ShortcodeRegistry) scan(content string, fn func(Shortcode)) {
+	for i := 0; i < len(content); {
+		j := strings.IndexByte(content[i:], '[')
+		if j < 0 {
+			return
+		}
+		i += j
+		sc, ok := r.match(content, i)
+		if !ok {
+			i++
+			continue
+		}
+		fn(sc)
+		i = sc.End
+	}
+}
+
+// match matches a registered shortcode starting at the "[" at i.
+func (r *ShortcodeRegistry) match(content string, i int) (Shortcode, bool) {
+	if i+1 < len(content) && content[i+1] == '[' {
+		if sc, ok := r.matchAt(content, i, i+2, true); ok {
+			return sc, true
+		}
+	}
+	return r.matchAt(content, i, i+1, false)
+}
+
+// matchAt matches the rest of a shortcode whose tag starts at pos. doubled
+// reports whether the opening bracket at start is followed by a second one.
+func (r *ShortcodeRegistry) matchAt(content string, start, pos int, doubled bool) (Shortcode, bool) {
+	tag := r.tagAt(content, pos)
+	if tag == "" {
+		return Shortcode{}, false
+	}
+	pos += len(tag)
+
+	// Attributes run to the first "]", which closes the opening tag; a "/" right
+	// before it makes the shortcode self-closing.
+	end := strings.IndexByte(content[pos:], ']')
+	if end < 0 {
+		return Shortcode{}, false
+	}
+	end += pos
+	sc := Shortcode{Tag: tag, registry: r}
+	attrText := content[pos:end]
+	pos = end + 1
+	if strings.HasSuffix(attrText, "/") {
+		sc.SelfClosing = true
+		attrText = attrText[:len(attrText)-1]
+	} else if closing := strings.Index(content[pos:], "[/"+tag+"]"); closing >= 0 {
+		sc.Closed = true
+		sc.Content = content[pos : pos+closing]
+		pos += closing + len("[/"+tag+"]")
+	}
+	sc.Attrs, sc.Args = parseShortcodeAttrs(attrText)
+	sc.Start, sc.End = start, pos
+	if doubled {
+		sc.Start++
+		if pos < len(content) && content[pos] == ']' {
+			sc.Escaped = true
+			sc.Start, sc.End = start, pos+1
+		}
+	}
+	sc.Raw = content[sc.Start:sc.End]
+	return sc, true
+}
+
+// tagAt returns the longest registered tag at pos that is not followed by a word
+// character or hyphen.
+func (r *ShortcodeRegistry) tagAt(content string, pos int) string {
+	best := ""
+	for tag := range r.handlers {
+		if len(tag) <= len(best) || !strings.HasPrefix(content[pos:], tag) {
+			continue
+		}
+		if next := pos + len(tag); next < len(content) && isShortcodeWordChar(content[next]) {
+			continue
+		}
+		best = tag
+	}
+	return best
+}
+
+func isShortcodeWordChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isShortcodeSpace(c byte) bool {
+	return strings.IndexByte(" \t\n\v\f\r", c) >= 0
+}
+
+// parseShortcodeAttrs parses the attribute text of a shortcode as WordPress's
+// shortcode_parse_atts() does: name="value", name='value', name=value, "value",
+// 'value' and bare values, separated by whitespace.
+func parseShortcodeAttrs(text string) (map[string]string, []string) {
+	attrs := make(map[string]string)
+	var args []string
+	text = strings.NewReplacer("\u00a0", " ", "\u200b", " ").Replace(text)
+
+	for i := 0; i < len(text); {
+		if name, value, n, ok := matchNamedAttr(text[i:]); ok {
+			if !phpEmpty(name) {
+				attrs[strings.ToLower(name)] = stripCSlashes(value)
+			}
+			i += n
+			continue
+		}
+		if value, n, ok := matchQuotedAttr(text[i:]); ok {
+			if value != "" {
+				args = append(args, stripCSlashes(value))
+			}
+			i += n
+			continue
+		}
+		if isShortcodeSpace(text[i]) {
+			i++
+			continue
+		}
+		// A bare value runs to the next whitespace
+		n := i
+		for n < len(text) && !isShortcodeSpace(text[n]) {
+			n++
+		}
+		args = append(args, stripCSlashes(text[i:n]))
+		i = n
+		if i < len(text) {
+			i++
+		}
+	}
+
+	// Reject values with unclosed HTML elements
+	for name, value := range attrs {
+		if !balancedTags(value) {
+			attrs[name] = ""
+		}
+	}
+	for k, value := range args {
+		if !balancedTags(value) {
+			args[k] = ""
+		}
+	}
+	return attrs, args
+}
+
+// matchNamedAttr matches name="value", name='value' or name=value followed by
+// whitespace or the end of the text, returning the length consumed.
+func matchNamedAttr(s string) (name, value string, n int, ok bool) {
+	for n < len(s) && isShortcodeWordChar(s[n]) {
+		n++
+	}
+	if n == 0 {
+		return "", "", 0, false
+	}
+	name = s[:n]
+	for n < len(s) && isShortcodeSpace(s[n]) {
+		n++
+	}
+	if n >= len(s) || s[n] != '=' {
+		return "", "", 0, false
+	}
+	n++
+	for n < len(s) && isShortcodeSpace(s[n]) {
+		n++
+	}
+	if n >= len(s) {
+		return "", "", 0, false
+	}
+
+	if q := s[n]; q == '"' || q == '\'' {
+		end := strings.IndexByte(s[n+1:], q)
+		if end >= 0 {
+			after := n + 1 + end + 1
+			if after == len(s) || isShortcodeSpace(s[after]) {
+				return name, s[n+1 : n+1+end], min(after+1, len(s)), true
+			}
+		}
+		// name="unterminated or name="a"b is not a quoted attribute; neither
+		// can an unquoted value start with a quote.
+		return "", "", 0, false
+	}
+
+	start := n
+	for n < len(s) && !isShortcodeSpace(s[n]) && s[n] != '"' && s[n] != '\'' {
+		n++
+	}
+	if n < len(s) && !isShortcodeSpace(s[n]) {
+		return "", "", 0, false
+	}
+	return name, s[start:n], min(n+1, len(s)), true
+}
+
+// matchQuotedAttr matches "value" or 'value' followed by whitespace or the end of the text.
+func matchQuotedAttr(s string) (string, int, bool) {
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return "", 0, false
+	}
+	end := strings.IndexByte(s[1:], s[0])
+	if end < 0 {
+		return "", 0, false
+	}
+	after := 1 + end + 1
+	if after < len(s) && !isShortcodeSpace(s[after]) {
+		return "", 0, false
+	}
+	return s[1 : 1+end], min(after+1, len(s)), true
+}
+
+// balancedTags reports whether every "<" in s is followed by a ">".
+func balancedTags(s string) bool {
+	open := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			open = true
+		case '>':
+			open = false
+		}
+	}
+	return !open
+}
+
+// stripCSlashes removes C-style backslash escapes as PHP's stripcslashes() does.
+func stripCSlashes(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'v':
+			b.WriteByte('\v')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'x':
+			n := 0
+			for n < 2 && i+1+n < len(s) && isHexDigit(s[i+1+n]) {
+				n++
+			}
+			if n == 0 {
+				b.WriteByte('x')
+				continue
+			}
+			v, _ := strconv.ParseUint(s[i+1:i+1+n], 16, 8)
+			b.WriteByte(byte(v))
+			i += n
+		default:
+			if c >= '0' && c <= '7' {
+				n := 1
+				for n < 3 && i+n < len(s) && s[i+n] >= '0' && s[i+n] <= '7' {
+					n++
+				}
+				v, _ := strconv.ParseUint(s[i:i+n], 8, 16)
+				b.WriteByte(byte(v))
+				i += n - 1
+				continue
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// captionShortcode renders [caption] as a figure, like WordPress's
+// img_caption_shortcode() with HTML5 support. The caption is the caption
+// attribute or, in the current format, the text after the image.
+func captionShortcode(sc Shortcode) string {
+	content := sc.Content
+	caption := sc.Attr("caption", "")
+	if caption == "" {
+		content, caption = splitCaption(content)
+	}
+
+	var b strings.Builder
+	b.WriteString("<figure")
+	if id := sc.Attr("id", ""); id != "" {
+		b.WriteString(` id="` + html.EscapeString(id) + `"`)
+	}
+	class := strings.TrimSpace("wp-caption " + sc.Attr("align", "alignnone") + " " + sc.Attr("class", ""))
+	b.WriteString(` class="` + html.EscapeString(class) + `"`)
+	if width, err := strconv.Atoi(sc.Attr("width", "")); err == nil && width > 0 {
+		b.WriteString(` style="width: ` + strconv.Itoa(width) + `px"`)
+	}
+	b.WriteString(">")
+	b.WriteString(sc.expand(content))
+	if caption != "" {
+		b.WriteString(`<figcaption class="wp-caption-text">` + caption + `</figcaption>`)
+	}
+	b.WriteString("</figure>")
+	return b.String()
+}
+
+// splitCaption splits caption content into the image, optionally wrapped in a
+// link, and the caption text that follows it. Text before the image is dropped,
+// as WordPress does.
+func splitCaption(content string) (image, caption string) {
+	lower := strings.ToLower(content)
+	for i := strings.IndexByte(lower, '<'); i >= 0; {
+		end := -1
+		if strings.HasPrefix(lower[i:], "<a ") {
+			if tag := tagEnd(lower, i+len("<a ")); tag >= 0 {
+				if img := skipHTMLSpace(lower, tag); strings.HasPrefix(lower[img:], "<img ") {
+					end = tagEnd(lower, img+len("<img "))
+				}
+			}
+		}
+		if end < 0 && strings.HasPrefix(lower[i:], "<img ") {
+			end = tagEnd(lower, i+len("<img "))
+		}
+		if end >= 0 {
+			if closing := skipHTMLSpace(lower, end); strings.HasPrefix(lower[closing:], "</a>") {
+				end = closing + len("</a>")
+			}
+			return content[i:end], strings.TrimSpace(content[end:])
+		}
+		next := strings.IndexByte(lower[i+1:], '<')
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	return content, ""
+}
+
+// tagEnd returns the index after the ">" closing a tag whose attributes start at
+// i, or -1 if there are no attributes or no ">".
+func tagEnd(s string, i int) int {
+	j := strings.IndexByte(s[i:], '>')
+	if j <= 0 {
+		return -1
+	}
+	return i + j + 1
+}
+
+// skipHTMLSpace returns the index of the first non-whitespace byte at or after i.
+func skipHTMLSpace(s string, i int) int {
+	for i < len(s) && isShortcodeSpace(s[i]) {
+		i++
+	}
+	return i
+}
+
+// galleryShortcode renders [gallery] with the images of the attachments listed in
+// its ids (or include) attribute. Attachments the registry does not know are skipped.
+func (r *ShortcodeRegistry) galleryShortcode(sc Shortcode) string {
+	columns := sc.Attr("columns", "3")
+	size := sc.Attr("size", "thumbnail")
+
+	var b strings.Builder
+	b.WriteString(`<div class="gallery gallery-columns-` + html.EscapeString(columns) + `">`)
+	for _, field := range strings.Split(sc.Attr("ids", sc.Attr("include", "")), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			continue
+		}
+		a, ok := r.attachments[id]
+		if !ok || a.URL == "" {
+			continue
+		}
+		src := a.URL
+		if s, ok := a.Sizes[size]; ok && s.URL != "" {
+			src = s.URL
+		}
+		b.WriteString(`<figure class="gallery-item"><img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(a.AltText) + `">`)
+		if a.Caption != "" {
+			b.WriteString(`<figcaption class="wp-caption-text gallery-caption">` + a.Caption + `</figcaption>`)
+		}
+		b.WriteString("</figure>")
+	}
+	b.WriteString("</div>")
+	return b.String()
+}
+
+// embedShortcode renders [embed]URL[/embed] as a link to the embedded URL,
+// since oEmbed discovery needs the network.
+func embedShortcode(sc Shortcode) string {
+	url := strings.TrimSpace(sc.Content)
+	if url == "" {
+		url = sc.Attr("src", "")
+	}
+	if url == "" && len(sc.Args) > 0 {
+		url = sc.Args[0]
+	}
+	if url == "" {
+		return ""
+	}
+	escaped := html.EscapeString(url)
+	return `<figure class="wp-embed"><a href="` + escaped + `">` + escaped + `</a></figure>`
+}
+
+// videoShortcode renders [video] as an HTML5 video element.
+func videoShortcode(sc Shortcode) string {
+	var b strings.Builder
+	b.WriteString(`<video controls`)
+	for _, name := range []string{"width", "height", "poster", "preload"} {
+		if v := sc.Attr(name, ""); v != "" {
+			b.WriteString(" " + name + `="` + html.EscapeString(v) + `"`)
+		}
+	}
+	for _, name := range []string{"loop", "autoplay", "muted"} {
+		if v := sc.Attr(name, ""); v == "on" || v == "1" || v == "true" {
+			b.WriteString(" " + name)
+		}
+	}
+	b.WriteString(">")
+	writeMediaSources(&b, sc, []string{"mp4", "m4v", "webm", "ogv", "wmv", "flv"})
+	b.WriteString(sc.ExpandContent())
+	b.WriteString("</video>")
+	return b.String()
+}
+
+// audioShortcode renders [audio] as an HTML5 audio element.
+func audioShortcode(sc Shortcode) string {
+	var b strings.Builder
+	b.WriteString(`<audio controls`)
+	if v := sc.Attr("preload", ""); v != "" {
+		b.WriteString(` preload="` + html.EscapeString(v) + `"`)
+	}
+	for _, name := range []string{"loop", "autoplay"} {
+		if v := sc.Attr(name, ""); v == "on" || v == "1" || v == "true" {
+			b.WriteString(" " + name)
+		}
+	}
+	b.WriteString(">")
+	writeMediaSources(&b, sc, []string{"mp3", "ogg", "flac", "m4a", "wav", "wma"})
+	b.WriteString(sc.ExpandContent())
+	b.WriteString("</audio>")
+	return b.String()
+}
+
+// writeMediaSources writes a <source> element for the src attribute and for each
+// format-specific attribute of a media shortcode.
+func writeMediaSources(b *strings.Builder, sc Shortcode, formats []string) {
+	urls := []string{sc.Attr("src", "")}
+	if len(sc.Args) > 0 {
+		urls[0] = sc.Args[0]
+	}
+	for _, format := range formats {
+		urls = append(urls, sc.Attr(format, ""))
+	}
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		b.WriteString(`<source src="` + html.EscapeString(url) + `"`)
+		if t := mimeTypeByExtension(url); t != "" {
+			b.WriteString(` type="` + t + `"`)
+		}
+		b.WriteString(">")
+	}
+}
@@ -0,0 +1,153 @@
+package wxr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShortcodeRegistry_Parse(t *testing.T) {
+	r := NewShortcodeRegistry()
+	content := `Intro [gallery ids="1,2,3" columns=2 link='file'] text ` +
+		`[caption id="attachment_5" align="alignright" width="300"]<img src="a.jpg" /> A caption[/caption] ` +
+		`[video src="clip.mp4" /] [embed]https://youtu.be/x[/embed] [[audio src="x.mp3"]] [unknown]`
+
+	shortcodes := r.Parse(content)
+	var tags []string
+	for _, sc := range shortcodes {
+		tags = append(tags, sc.Tag)
+	}
+	if want := []string{"gallery", "caption", "video", "embed", "audio"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags = %q, want %q", tags, want)
+	}
+
+	gallery := shortcodes[0]
+	wantAttrs := map[string]string{"ids": "1,2,3", "columns": "2", "link": "file"}
+	if !reflect.DeepEqual(gallery.Attrs, wantAttrs) || gallery.Closed || gallery.SelfClosing {
+		t.Errorf("unexpected gallery: %+v", gallery)
+	}
+	if gallery.Raw != `[gallery ids="1,2,3" columns=2 link='file']` || content[gallery.Start:gallery.End] != gallery.Raw {
+		t.Errorf("unexpected gallery raw %q", gallery.Raw)
+	}
+
+	caption := shortcodes[1]
+	if !caption.Closed || caption.Content != `<img src="a.jpg" /> A caption` || caption.Attr("WIDTH", "") != "300" {
+		t.Errorf("unexpected caption: %+v", caption)
+	}
+	if video := shortcodes[2]; !video.SelfClosing || video.Attr("src", "") != "clip.mp4" {
+		t.Errorf("unexpected video: %+v", video)
+	}
+	if audio := shortcodes[4]; !audio.Escaped || audio.Raw != `[[audio src="x.mp3"]]` {
+		t.Errorf("unexpected escaped audio: %+v", audio)
+	}
+}
+
+func TestParseShortcodeAttrs(t *testing.T) {
+	tests := []struct {
+		in    string
+		attrs map[string]string
+		args  []string
+	}{
+		{` id="1" Title="It's" size = large`, map[string]string{"id": "1", "title": `It's`, "size": "large"}, nil},
+		{` q='It\'s'`, map[string]string{}, []string{`q='It's'`}},
+		{` "https://example.com" 'quoted' bare`, map[string]string{}, []string{"https://example.com", "quoted", "bare"}},
+		{` name="a"b`, map[string]string{}, []string{`name="a"b`}},
+		{" text=\"line\\none\"", map[string]string{"text": "line\none"}, nil},
+		{" a=\"1\" b=\"2\"", map[string]string{"a": "1", "b": "2"}, nil},
+		{` html="<b>ok</b>" broken="<b"`, map[string]string{"html": "<b>ok</b>", "broken": ""}, nil},
+		{``, map[string]string{}, nil},
+	}
+	for _, tt := range tests {
+		attrs, args := parseShortcodeAttrs(tt.in)
+		if !reflect.DeepEqual(attrs, tt.attrs) || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("parseShortcodeAttrs(%q) = %q, %q, want %q, %q", tt.in, attrs, args, tt.attrs, tt.args)
+		}
+	}
+}
+
+func TestShortcodeRegistry_Expand(t *testing.T) {
+	r := NewEmptyShortcodeRegistry().
+		Register("b", func(sc Shortcode) string { return "<strong>" + sc.ExpandContent() + "</strong>" }).
+		Register("i", func(sc Shortcode) string { return "<em>" + sc.Content + "</em>" }).
+		Register("ad", StripShortcode).
+		Register("box", UnwrapShortcode).
+		Register("keep", KeepShortcode)
+
+	tests := []struct {
+		in, want string
+	}{
+		{"[b]bold [i]it[/i][/b]", "<strong>bold <em>it</em></strong>"},
+		{"[i][b]raw[/b][/i]", "<em>[b]raw[/b]</em>"},
+		{"a [ad id=1] b", "a  b"},
+		{"[box]inside [i]x[/i][/box]", "inside <em>x</em>"},
+		{"[[b]literal[/b]]", "[b]literal[/b]"},
+		{"[[ad]", "["},
+		{"[keep x=1]", "[keep x=1]"},
+		{"[bold]not a tag[/bold] [b-x] [b", "[bold]not a tag[/bold] [b-x] [b"},
+		{"no shortcodes", "no shortcodes"},
+	}
+	for _, tt := range tests {
+		if got := r.Expand(tt.in); got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if got := r.Strip("x [b]bold[/b] [[i]] y"); got != "x  [i] y" {
+		t.Errorf("Strip() = %q", got)
+	}
+}
+
+func TestShortcodeRegistry_BuiltIns(t *testing.T) {
+	r := NewShortcodeRegistry().WithAttachments([]Attachment{
+		{ID: 1, URL: "https://example.com/a.jpg", AltText: "A", Caption: "First", Sizes: map[string]AttachmentSize{
+			"thumbnail": {URL: "https://example.com/a-150x150.jpg"},
+		}},
+		{ID: 2, URL: "https://example.com/b.jpg"},
+	})
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"caption in content",
+			`[caption id="attachment_5" align="alignright" width="300"]<a href="/x"><img src="a.jpg" /></a> Sunset[/caption]`,
+			`<figure id="attachment_5" class="wp-caption alignright" style="width: 300px"><a href="/x"><img src="a.jpg" /></a><figcaption class="wp-caption-text">Sunset</figcaption></figure>`,
+		},
+		{
+			"caption attribute",
+			`[caption caption="Old style"]<img src="a.jpg">[/caption]`,
+			`<figure class="wp-caption alignnone"><img src="a.jpg"><figcaption class="wp-caption-text">Old style</figcaption></figure>`,
+		},
+		{
+			"gallery",
+			`[gallery ids="1, 2, 9" columns="2"]`,
+			`<div class="gallery gallery-columns-2"><figure class="gallery-item"><img src="https://example.com/a-150x150.jpg" alt="A"><figcaption class="wp-caption-text gallery-caption">First</figcaption></figure><figure class="gallery-item"><img src="https://example.com/b.jpg" alt=""></figure></div>`,
+		},
+		{
+			"embed",
+			`[embed width="500"]https://youtu.be/x?a=1&b=2[/embed]`,
+			`<figure class="wp-embed"><a href="https://youtu.be/x?a=1&amp;b=2">https://youtu.be/x?a=1&amp;b=2</a></figure>`,
+		},
+		{
+			"video",
+			`[video width="640" mp4="https://example.com/v.mp4" loop="on"][/video]`,
+			`<video controls width="640" loop><source src="https://example.com/v.mp4" type="video/mp4"></video>`,
+		},
+		{
+			"audio",
+			`[audio src="https://example.com/s.mp3"]`,
+			`<audio controls><source src="https://example.com/s.mp3" type="audio/mpeg"></audio>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Expand(tt.in); got != tt.want {
+				t.Errorf("Expand() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if tags := strings.Join(r.Tags(), ","); tags != "audio,caption,embed,gallery,video,wp_caption" {
+		t.Errorf("Tags() = %s", tags)
+	}
+}
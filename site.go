@@ -0,0 +1,64 @@
+package wxr
+
+import "strings"
+
+// Site describes the WordPress site an export was taken from, as declared by the
+// <channel> element of the document.
+type Site struct {
+	// Title is the site title.
+	Title string
+
+	// Link is the site URL.
+	Link string
+
+	// Description is the site tagline.
+	Description string
+
+	// Language is the site language, such as "pt-BR".
+	Language string
+
+	// PubDate is the date the export was generated, in RFC3339 format when it can be parsed.
+	PubDate string
+
+	// Generator identifies the software that produced the export,
+	// such as "https://wordpress.org/?v=6.4.2".
+	Generator string
+
+	// WXRVersion is the WXR format version declared by wp:wxr_version, such as "1.2".
+	WXRVersion string
+
+	// NamespaceVersion is the WXR version of the WordPress namespace the export
+	// uses: "1.0", "1.1" or "1.2", detected from its namespace declarations.
+	// Exports of all three versions are decoded alike.
+	NamespaceVersion string
+
+	// BaseSiteURL is the root URL of the WordPress installation (wp:base_site_url).
+	BaseSiteURL string
+
+	// BaseBlogURL is the URL of the blog (wp:base_blog_url).
+	BaseBlogURL string
+
+	// Extra are the channel elements the package does not map, such as
+	// <image> or elements added by plugins, in document order.
+	Extra Nodes
+}
+
+// buildSite converts the channel-level site information.
+func buildSite(ch channel) Site {
+	site := Site{
+		Title:            strings.TrimSpace(ch.Title),
+		Link:             strings.TrimSpace(ch.Link),
+		Description:      strings.TrimSpace(ch.Description),
+		Language:         strings.TrimSpace(ch.Language),
+		Generator:        strings.TrimSpace(ch.Generator),
+		WXRVersion:       strings.TrimSpace(ch.WXRVersion),
+		NamespaceVersion: ch.NamespaceVersion,
+		BaseSiteURL:      strings.TrimSpace(ch.BaseSiteURL),
+		BaseBlogURL:      strings.TrimSpace(ch.BaseBlogURL),
+		Extra:            ch.Extra,
+	}
+	if pubDate := strings.TrimSpace(ch.PubDate); pubDate != "" {
+		site.PubDate = normalizeWXRDate(pubDate)
+	}
+	return site
+}
@@ -0,0 +1,69 @@
+package wxr
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const siteXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Diário do Sul</title>
+	<link>https://diariodosul.example.com</link>
+	<description>Notícias do Sul</description>
+	<pubDate>Tue, 02 Jan 2024 15:04:05 +0000</pubDate>
+	<language>pt-BR</language>
+	<wp:wxr_version>1.2</wp:wxr_version>
+	<wp:base_site_url>https://diariodosul.example.com</wp:base_site_url>
+	<wp:base_blog_url>https://diariodosul.example.com/blog</wp:base_blog_url>
+	<generator>https://wordpress.org/?v=6.4.2</generator>
+	<item>
+		<title><![CDATA[Post]]></title>
+		<description></description>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+func TestParseDocument_Site(t *testing.T) {
+	doc, err := ParseDocument(context.Background(), strings.NewReader(siteXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+
+	want := Site{
+		Title:            "Diário do Sul",
+		Link:             "https://diariodosul.example.com",
+		Description:      "Notícias do Sul",
+		Language:         "pt-BR",
+		PubDate:          "2024-01-02T15:04:05Z",
+		Generator:        "https://wordpress.org/?v=6.4.2",
+		WXRVersion:       "1.2",
+		NamespaceVersion: "1.2",
+		BaseSiteURL:      "https://diariodosul.example.com",
+		BaseBlogURL:      "https://diariodosul.example.com/blog",
+	}
+	if !reflect.DeepEqual(doc.Site, want) {
+		t.Errorf("Site = %+v, want %+v", doc.Site, want)
+	}
+	if len(doc.Posts) != 1 {
+		t.Errorf("expected 1 post, got %d", len(doc.Posts))
+	}
+}
+
+func TestStream_Site(t *testing.T) {
+	stream := NewParser().NewStream(strings.NewReader(siteXML))
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if site := stream.Site(); site.WXRVersion != "1.2" || site.BaseSiteURL != "https://diariodosul.example.com" {
+		t.Errorf("unexpected stream site: %+v", site)
+	}
+}
@@ -0,0 +1,736 @@
+package wxr
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sort"
+	"strings"
+)
+
+// wpNamespace is the XML namespace of the WordPress-specific WXR elements.
+const wpNamespace = "http://wordpress.org/export/1.2/"
+
+// wpNamespaceBase is the part of the WordPress namespaces before the version.
+const wpNamespaceBase = "http://wordpress.org/export/"
+
+// upgradeNamespace maps the WordPress namespaces of WXR 1.0 and 1.1, including
+// their excerpt namespaces, to the 1.2 equivalent. It returns the version of a
+// WordPress namespace, or "" for other namespaces, which are returned unchanged.
+func upgradeNamespace(space string) (upgraded, version string) {
+	rest, ok := strings.CutPrefix(space, wpNamespaceBase)
+	if !ok {
+		return space, ""
+	}
+	version, path, ok := strings.Cut(rest, "/")
+	if !ok {
+		return space, ""
+	}
+	switch version {
+	case "1.0", "1.1", "1.2":
+		return wpNamespaceBase + "1.2/" + path, version
+	}
+	return space, ""
+}
+
+// AttachmentMode controls how a Stream resolves featured images that point to
+// attachments, which may appear after the posts that reference them.
+type AttachmentMode int
+
+const (
+	// AttachmentsSeen resolves featured images against the attachments decoded so far.
+	// Posts are emitted as soon as they are read, but a post whose attachment appears
+	// later in the document gets no featured image from the attachment index.
+	AttachmentsSeen AttachmentMode = iota
+
+	// AttachmentsDeferred holds back posts whose _thumbnail_id refers to an attachment
+	// that has not been decoded yet. Each held post is emitted as soon as its attachment
+	// appears, or at the end of the document if it never does, so posts may be emitted
+	// out of document order.
+	//
+	// Posts without a _thumbnail_id are not held back, so the fallback to the first
+	// attachment uploaded to the post, which WordPress exports after it, only applies
+	// when that attachment was decoded before the post. Parse and AttachmentsTwoPass
+	// apply it to every post.
+	AttachmentsDeferred
+
+	// AttachmentsTwoPass reads the document twice: the first pass only indexes
+	// attachments and the second emits posts. Results match Parse exactly, but the
+	// reader given to NewStream must implement io.Seeker.
+	AttachmentsTwoPass
+)
+
+// tokenSource feeds raw tokens to the decoder used for unmarshalling while tracking
+// element depth and syntax errors, so the reader can resynchronise after an item
+// fails to unmarshal. The WordPress namespaces of WXR 1.0 and 1.1 are rewritten
+// to 1.2, so that the structs in xml.go decode every version.
+type tokenSource struct {
+	raw     *xml.Decoder
+	depth   int
+	err     error
+	version string // WXR version of the first WordPress namespace seen
+
+	// open holds the position of each open element and closed the last element
+	// closed, so that item errors can name the field that failed.
+	open   []elementPos
+	closed elementPos
+
+	// base is the input position of the first byte after prefix, for decoders
+	// restarted in recovery mode.
+	base   inputPos
+	prefix int
+
+	// record keeps the tokens read, so that an item can be decoded again in
+	// recovery mode.
+	record bool
+	tokens []recordedToken
+}
+
+// elementPos is the position of an element's start tag, and its index in the
+// recorded tokens.
+type elementPos struct {
+	name         xml.Name
+	line, column int
+	index        int
+}
+
+func (ts *tokenSource) Token() (xml.Token, error) {
+	pos := ts.position()
+	tok, err := ts.raw.Token()
+	if err != nil && err != io.EOF {
+		ts.err = ts.syntaxError(err)
+		return nil, ts.err
+	}
+	switch t := tok.(type) {
+	case xml.StartElement:
+		ts.depth++
+		ts.open = append(ts.open, elementPos{name: t.Name, line: pos.line, column: pos.column, index: len(ts.tokens)})
+		t.Name.Space = ts.upgrade(t.Name.Space)
+		for i, a := range t.Attr {
+			if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+				t.Attr[i].Value = ts.upgrade(a.Value)
+			} else {
+				t.Attr[i].Name.Space = ts.upgrade(a.Name.Space)
+			}
+		}
+		tok = t
+	case xml.EndElement:
+		ts.depth--
+		t.Name.Space = ts.upgrade(t.Name.Space)
+		tok = t
+		if n := len(ts.open); n > 0 {
+			ts.closed = ts.open[n-1]
+			ts.open = ts.open[:n-1]
+		}
+	}
+	if ts.record && tok != nil {
+		ts.tokens = append(ts.tokens, recordedToken{token: xml.CopyToken(tok), line: pos.line, column: pos.column})
+	}
+	return tok, err
+}
+
+// position returns the position in the input where the decoder stopped.
+func (ts *tokenSource) position() inputPos {
+	line, column := ts.raw.InputPos()
+	offset := ts.raw.InputOffset() - int64(ts.prefix)
+	if line == 1 {
+		column += ts.base.column - 1 - ts.prefix
+	} else {
+		line += ts.base.line - 1
+	}
+	return inputPos{line: line, column: column, offset: ts.base.offset + offset}
+}
+
+// syntaxError converts an *xml.SyntaxError into a *SyntaxError at the current
+// position. Other errors, such as read errors, are returned unchanged.
+func (ts *tokenSource) syntaxError(err error) error {
+	var xmlErr *xml.SyntaxError
+	if !errors.As(err, &xmlErr) {
+		return err
+	}
+	pos := ts.position()
+	return &SyntaxError{Line: pos.line, Column: pos.column, Offset: pos.offset, Msg: xmlErr.Msg, Err: err}
+}
+
+// parseError wraps a document-level error. A *SyntaxError already describes
+// itself and is returned as is.
+func (ts *tokenSource) parseError(err error) error {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr
+	}
+	if err = ts.syntaxError(err); errors.As(err, &syntaxErr) {
+		return syntaxErr
+	}
+	return fmt.Errorf("wxr: failed to parse WXR XML: %w", err)
+}
+
+// upgrade rewrites a WordPress namespace to WXR 1.2, recording the version of
+// the first one seen.
+func (ts *tokenSource) upgrade(space string) string {
+	upgraded, version := upgradeNamespace(space)
+	if ts.version == "" {
+		ts.version = version
+	}
+	return upgraded
+}
+
+// itemReader walks a WXR document token by token and decodes one <item> at a time,
+// so memory use is bounded by the largest single item instead of the whole export.
+// Channel-level elements are accumulated into channel as they are encountered;
+// channel.Items is never populated.
+type itemReader struct {
+	source    *tokenSource
+	decoder   *xml.Decoder
+	channel   channel
+	index     int  // number of items read so far
+	started   bool // root <rss> element seen
+	inChannel bool
+	done      bool // root element closed
+	err       error
+
+	// input is set in recovery mode; see recover.
+	input  *recoveryReader
+	root   xml.StartElement
+	inItem bool // an item is being decoded
+	itemID int  // post ID of the item being decoded, when read
+
+	// mojibakePasses is the number of layers of double encoding repaired in the
+	// text of items and channel elements, or 0; see Parser.WithMojibakeRepair.
+	mojibakePasses int
+}
+
+// newItemReader creates an itemReader for r, converted to UTF-8 according to
+// its XML declaration and the parser's charset options. In recovery mode,
+// problems that can be worked around are recorded in channel.Diagnostics
+// instead of being returned.
+func newItemReader(r io.Reader, p *Parser) *itemReader {
+	ir := &itemReader{}
+	if p.mojibake {
+		ir.mojibakePasses = max(p.mojibakePasses, 1)
+	}
+	r, charset, err := transcode(r, p.charsetReader)
+	if p.recovery {
+		if err != nil {
+			ir.diagnose(Diagnostic{
+				Action:  DiagnosticRepaired,
+				Message: fmt.Sprintf("read encoding %q as UTF-8: %v", charset, err),
+			})
+		}
+		ir.input = newRecoveryReader(r)
+		r = ir.input
+	}
+	ir.open(r, inputPos{line: 1, column: 1}, 0)
+	if err != nil && !p.recovery {
+		ir.err = err
+	}
+	return ir
+}
+
+// open starts decoding r, whose first prefix bytes are not part of the input
+// and the following ones start at base.
+func (ir *itemReader) open(r io.Reader, base inputPos, prefix int) {
+	raw := xml.NewDecoder(r)
+
+	// Handle CDATA sections properly
+	raw.Strict = false
+
+	// Decode HTML entities such as &nbsp; in text outside CDATA
+	raw.Entity = htmlEntities()
+
+	// The input was converted to UTF-8 by newItemReader
+	raw.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	version := ""
+	if ir.source != nil {
+		version = ir.source.version
+	}
+	ir.source = &tokenSource{raw: raw, version: version, base: base, prefix: prefix}
+	ir.decoder = xml.NewTokenDecoder(ir.source)
+}
+
+// next returns the next <item> of the document, or io.EOF once the root element
+// has been fully read. An *ItemError means only that item was lost and next may be
+// called again; any other error is sticky.
+func (ir *itemReader) next() (*item, error) {
+	for {
+		if ir.err != nil {
+			return nil, ir.err
+		}
+		it, err := ir.advance()
+		if it != nil && ir.mojibakePasses > 0 {
+			if fields := repairItemMojibake(it, ir.mojibakePasses); len(fields) > 0 {
+				ir.diagnose(Diagnostic{
+					Action:  DiagnosticRepaired,
+					Item:    ir.index,
+					PostID:  it.PostID,
+					Message: "double-encoded UTF-8 in " + strings.Join(fields, ", "),
+				})
+			}
+		}
+		if ir.input != nil && err != nil && err != io.EOF && ir.recover(err) {
+			continue
+		}
+		if _, ok := err.(*ItemError); err != nil && !ok {
+			ir.err = err
+		}
+		if err == io.EOF && ir.input != nil {
+			ir.input.report(&ir.channel)
+		}
+		return it, err
+	}
+}
+
+func (ir *itemReader) advance() (*item, error) {
+	for !ir.done {
+		tok, err := ir.decoder.Token()
+		if err == io.EOF {
+			if !ir.started {
+				pos := ir.source.position()
+				return nil, &SyntaxError{
+					Line:   pos.line,
+					Column: pos.column,
+					Offset: pos.offset,
+					Msg:    "unexpected EOF before the root element",
+					Err:    io.ErrUnexpectedEOF,
+				}
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, ir.source.parseError(err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if ir.channel.NamespaceVersion == "" {
+				ir.channel.NamespaceVersion = ir.source.version
+			}
+			switch {
+			case !ir.started:
+				// Validate that we actually got an RSS document
+				if t.Name.Local != "rss" {
+					return nil, fmt.Errorf("%w: root element is not <rss> (got %q)", ErrNotWXR, t.Name.Local)
+				}
+				ir.started = true
+				ir.root = t.Copy()
+			case !ir.inChannel:
+				if t.Name.Local == "channel" {
+					ir.inChannel = true
+				} else if err := ir.decoder.Skip(); err != nil {
+					return nil, ir.source.parseError(err)
+				}
+			case t.Name.Local == "item":
+				return ir.decodeItem(t)
+			default:
+				if ir.input != nil {
+					ir.input.mark()
+				}
+				if err := decodeChannelElement(ir.decoder, &ir.channel, t); err != nil {
+					return nil, ir.source.parseError(err)
+				}
+				if ir.mojibakePasses > 0 && repairChannelMojibake(&ir.channel, t, ir.mojibakePasses) {
+					ir.diagnose(Diagnostic{
+						Action:  DiagnosticRepaired,
+						Message: "double-encoded UTF-8 in " + qualifiedName(t.Name),
+					})
+				}
+			}
+		case xml.EndElement:
+			if ir.inChannel {
+				ir.inChannel = false
+			} else {
+				ir.done = true
+			}
+		}
+	}
+	return nil, io.EOF
+}
+
+// decodeItem unmarshals the <item> that starts with start. When the item is
+// well-formed XML but cannot be unmarshalled, the rest of it is skipped and an
+// *ItemError naming the element that failed is returned.
+func (ir *itemReader) decodeItem(start xml.StartElement) (*item, error) {
+	ir.index++
+	depth := ir.source.depth - 1
+	ir.source.closed = elementPos{}
+	ir.inItem, ir.itemID = true, 0
+	if ir.input != nil {
+		ir.input.mark()
+		ir.source.record = true
+		ir.source.tokens = append(ir.source.tokens[:0], recordedToken{token: start.Copy()})
+		defer func() { ir.source.record = false }()
+	}
+
+	var it item
+	err := ir.decoder.DecodeElement(&it, &start)
+	if err == nil {
+		ir.inItem = false
+		return &it, nil
+	}
+	ir.itemID = it.PostID
+	if ir.source.err != nil {
+		return nil, ir.source.parseError(ir.source.err)
+	}
+	field := ir.source.closed
+
+	// Resynchronise on the end of the broken item
+	for ir.source.depth > depth {
+		if _, skipErr := ir.decoder.Token(); skipErr != nil {
+			return nil, ir.source.parseError(skipErr)
+		}
+	}
+	ir.inItem = false
+	if ir.input != nil {
+		return ir.repairItem(field, err)
+	}
+	return nil, &ItemError{
+		Index:  ir.index,
+		PostID: it.PostID,
+		Field:  qualifiedName(field.name),
+		Line:   field.line,
+		Column: field.column,
+		Err:    err,
+	}
+}
+
+// decodeChannelElement decodes a non-item child of <channel> into ch.
+// Elements the channel struct does not map are kept in ch.Extra.
+func decodeChannelElement(d *xml.Decoder, ch *channel, start xml.StartElement) error {
+	switch {
+	case start.Name.Space == "" && start.Name.Local == "title":
+		return d.DecodeElement(&ch.Title, &start)
+	case start.Name.Space == "" && start.Name.Local == "link":
+		return d.DecodeElement(&ch.Link, &start)
+	case start.Name.Space == "" && start.Name.Local == "description":
+		return d.DecodeElement(&ch.Description, &start)
+	case start.Name.Space == "" && start.Name.Local == "pubDate":
+		return d.DecodeElement(&ch.PubDate, &start)
+	case start.Name.Space == "" && start.Name.Local == "language":
+		return d.DecodeElement(&ch.Language, &start)
+	case start.Name.Space == "" && start.Name.Local == "generator":
+		return d.DecodeElement(&ch.Generator, &start)
+	case start.Name.Space == wpNamespace && start.Name.Local == "wxr_version":
+		return d.DecodeElement(&ch.WXRVersion, &start)
+	case start.Name.Space == wpNamespace && start.Name.Local == "base_site_url":
+		return d.DecodeElement(&ch.BaseSiteURL, &start)
+	case start.Name.Space == wpNamespace && start.Name.Local == "base_blog_url":
+		return d.DecodeElement(&ch.BaseBlogURL, &start)
+	case start.Name.Space == wpNamespace && start.Name.Local == "author":
+		var author wpAuthor
+		if err := d.DecodeElement(&author, &start); err != nil {
+			return err
+		}
+		ch.Authors = append(ch.Authors, author)
+		return nil
+	case start.Name.Space == wpNamespace && start.Name.Local == "category":
+		var category wpCategoryTerm
+		if err := d.DecodeElement(&category, &start); err != nil {
+			return err
+		}
+		ch.Categories = append(ch.Categories, category)
+		return nil
+	case start.Name.Space == wpNamespace && start.Name.Local == "tag":
+		var tag wpTagTerm
+		if err := d.DecodeElement(&tag, &start); err != nil {
+			return err
+		}
+		ch.Tags = append(ch.Tags, tag)
+		return nil
+	case start.Name.Space == wpNamespace && start.Name.Local == "term":
+		var term wpTerm
+		if err := d.DecodeElement(&term, &start); err != nil {
+			return err
+		}
+		ch.Terms = append(ch.Terms, term)
+		return nil
+	default:
+		var node Node
+		if err := d.DecodeElement(&node, &start); err != nil {
+			return err
+		}
+		ch.Extra = append(ch.Extra, node)
+		return nil
+	}
+}
+
+// scanAttachments reads a whole document and indexes its attachments.
+// It is the first pass of AttachmentsTwoPass.
+func scanAttachments(r io.Reader, p *Parser) (*AttachmentIndex, error) {
+	index := newAttachmentIndex()
+	src := newItemReader(r, p)
+	for {
+		it, err := src.next()
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		index.add(it, determineUploadsBaseURL(src.channel))
+	}
+}
+
+// Stream decodes posts from a WXR document one <item> at a time.
+// Create one with Parser.NewStream. A Stream is not safe for concurrent use.
+type Stream struct {
+	parser  *Parser
+	r       io.Reader
+	mode    AttachmentMode
+	src     *itemReader
+	index   *channelIndex
+	terms   int // channel-level term definitions in index.taxonomies
+	authors int // channel-level author records in index.authors
+	logged  int // diagnostics logged so far
+	stats   *parseStats
+	pending map[int][]pendingItem
+	seq     int
+	ready   []Post
+	err     error
+}
+
+// pendingItem is an item held back by AttachmentsDeferred until its thumbnail appears.
+type pendingItem struct {
+	seq  int
+	item *item
+}
+
+// NewStream returns a Stream that decodes posts from r incrementally.
+// Items are selected and transformed exactly as Parse does; featured images are
+// resolved according to the parser's AttachmentMode.
+func (p *Parser) NewStream(r io.Reader) *Stream {
+	return &Stream{
+		parser: p,
+		r:      r,
+		mode:   p.attachmentMode,
+		index: &channelIndex{
+			attachments: newAttachmentIndex(),
+			taxonomies:  make(map[string]*Taxonomy),
+			byLogin:     make(map[string]Author),
+		},
+		stats:   newParseStats(),
+		pending: make(map[int][]pendingItem),
+	}
+}
+
+// Next returns the next post of the document.
+// It returns io.EOF when there are no more posts. An *ItemError about a single
+// malformed item is returned once and the following call continues with the
+// next item; any other error is returned again by every subsequent call.
+func (s *Stream) Next() (Post, error) {
+	for len(s.ready) == 0 {
+		if s.err != nil {
+			return Post{}, s.err
+		}
+		if err := s.fill(); err != nil {
+			return Post{}, err
+		}
+	}
+	post := s.ready[0]
+	s.ready = s.ready[1:]
+	return post, nil
+}
+
+// fill reads one item from the document and queues the posts it makes ready.
+// It returns item-level errors; document-level errors are stored in s.err.
+func (s *Stream) fill() error {
+	if s.src == nil {
+		if err := s.start(); err != nil {
+			s.err = err
+			return nil
+		}
+	}
+
+	it, err := s.src.next()
+	for _, d := range s.src.channel.Diagnostics[s.logged:] {
+		s.parser.logger.Printf("Worked around bad input: %v", d)
+	}
+	s.logged = len(s.src.channel.Diagnostics)
+	if err == io.EOF {
+		s.flush()
+		s.stats.log(s.parser.logger)
+		s.err = io.EOF
+		return nil
+	}
+	if itemErr, ok := err.(*ItemError); ok {
+		s.parser.logger.Printf("Skipping malformed item: %v", itemErr)
+		s.stats.skipped++
+		return itemErr
+	}
+	if err != nil {
+		s.err = err
+		return nil
+	}
+
+	s.refreshIndex()
+
+	if it.PostType == "attachment" {
+		s.index.attachments.add(it, determineUploadsBaseURL(s.src.channel))
+		s.release(it.PostID)
+	}
+
+	if !s.parser.includeItem(it, s.stats) {
+		return nil
+	}
+
+	if s.mode == AttachmentsDeferred {
+		if thumbID, ok := s.parser.featuredImageExt.pendingThumbnail(it, s.index.attachments); ok {
+			s.pending[thumbID] = append(s.pending[thumbID], pendingItem{seq: s.seq, item: it})
+			s.seq++
+			return nil
+		}
+	}
+	s.emit(it)
+	return nil
+}
+
+// All returns an iterator over the posts of the WXR document read from r.
+// Posts are decoded one at a time as with NewStream, so breaking out of the loop
+// stops reading the input. An *ItemError about a single malformed item is yielded with
+// a zero Post and iteration continues; a document-level error or the cancellation
+// of ctx is yielded once and ends the iteration.
+func (p *Parser) All(ctx context.Context, r io.Reader) iter.Seq2[Post, error] {
+	return func(yield func(Post, error) bool) {
+		stream := p.NewStream(r)
+		for {
+			select {
+			case <-ctx.Done():
+				yield(Post{}, ctx.Err())
+				return
+			default:
+			}
+
+			post, err := stream.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				if !yield(Post{}, err) || stream.err != nil {
+					return
+				}
+				continue
+			}
+			if !yield(post, nil) {
+				return
+			}
+		}
+	}
+}
+
+// All is a convenience function that iterates over the posts of a WXR file
+// using the default parser.
+func All(ctx context.Context, r io.Reader) iter.Seq2[Post, error] {
+	parser := NewParser()
+	return parser.All(ctx, r)
+}
+
+// start prepares the item reader, running the attachment pass first in AttachmentsTwoPass mode.
+func (s *Stream) start() error {
+	s.parser.logger.Printf("Starting WXR streaming")
+
+	if s.mode == AttachmentsTwoPass {
+		seeker, ok := s.r.(io.Seeker)
+		if !ok {
+			return errors.New("wxr: two-pass attachment resolution requires an io.Seeker")
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("wxr: failed to rewind input: %w", err)
+		}
+		index, err := scanAttachments(s.r, s.parser)
+		if err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("wxr: failed to rewind input: %w", err)
+		}
+		s.index.attachments = index
+		s.parser.logger.Printf("Indexed %d attachments", len(index.URLsByID))
+	}
+
+	s.src = newItemReader(s.r, s.parser)
+	return nil
+}
+
+// refreshIndex rebuilds the term and author lookups when new channel-level
+// definitions have been read. WordPress writes them before the first item.
+func (s *Stream) refreshIndex() {
+	ch := s.src.channel
+	if count := len(ch.Categories) + len(ch.Tags) + len(ch.Terms); count != s.terms {
+		s.index.taxonomies = buildTaxonomies(ch)
+		s.terms = count
+	}
+	if len(ch.Authors) != s.authors {
+		s.index.authors = buildAuthors(ch)
+		s.index.byLogin = authorsByLogin(s.index.authors)
+		s.authors = len(ch.Authors)
+	}
+}
+
+// Taxonomies returns the channel-level terms read so far, keyed by taxonomy name.
+// WordPress declares all terms before the first item, so they are complete once
+// Next has returned a post.
+func (s *Stream) Taxonomies() map[string]*Taxonomy {
+	return s.index.taxonomies
+}
+
+// Site returns the site information read so far. WordPress writes it before the
+// first item, so it is complete once Next has returned a post.
+func (s *Stream) Site() Site {
+	if s.src == nil {
+		return Site{}
+	}
+	return buildSite(s.src.channel)
+}
+
+// Authors returns the channel-level authors read so far.
+// WordPress declares all authors before the first item, so they are complete once
+// Next has returned a post.
+func (s *Stream) Authors() []Author {
+	return s.index.authors
+}
+
+// Diagnostics returns what was repaired or dropped so far in recovery mode and
+// by mojibake repair. See Parser.WithRecovery and Parser.WithMojibakeRepair.
+func (s *Stream) Diagnostics() []Diagnostic {
+	if s.src == nil {
+		return nil
+	}
+	return s.src.channel.Diagnostics
+}
+
+// emit transforms an item and queues the resulting post.
+func (s *Stream) emit(it *item) {
+	s.ready = append(s.ready, s.parser.buildPost(it, s.index))
+	s.stats.posts++
+}
+
+// release emits the posts that were waiting for the attachment with the given ID.
+func (s *Stream) release(attachmentID int) {
+	waiting, ok := s.pending[attachmentID]
+	if !ok {
+		return
+	}
+	delete(s.pending, attachmentID)
+	for _, p := range waiting {
+		s.emit(p.item)
+	}
+}
+
+// flush emits every post still waiting for an attachment, in document order.
+func (s *Stream) flush() {
+	var waiting []pendingItem
+	for id, items := range s.pending {
+		waiting = append(waiting, items...)
+		delete(s.pending, id)
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].seq < waiting[j].seq })
+	for _, p := range waiting {
+		s.emit(p.item)
+	}
+}
@@ -0,0 +1,275 @@
+package wxr
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// streamXML has a post whose thumbnail attachment appears after it, and a post
+// with no thumbnail, to exercise the attachment modes.
+const streamXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Test Site</title>
+	<link>https://example.com</link>
+	<item>
+		<title><![CDATA[First]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[_thumbnail_id]]></wp:meta_key>
+			<wp:meta_value><![CDATA[10]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title><![CDATA[Second]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[thumb]]></title>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_type><![CDATA[attachment]]></wp:post_type>
+		<wp:status><![CDATA[inherit]]></wp:status>
+		<wp:attachment_url><![CDATA[https://example.com/thumb.png]]></wp:attachment_url>
+	</item>
+	<item>
+		<title><![CDATA[Draft]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>3</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[draft]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+func readStream(t *testing.T, s *Stream) []Post {
+	t.Helper()
+	var posts []Post
+	for {
+		post, err := s.Next()
+		if err == io.EOF {
+			return posts
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		posts = append(posts, post)
+	}
+}
+
+func TestStream_AttachmentModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      AttachmentMode
+		wantOrder []int
+		wantImage string
+	}{
+		{name: "seen", mode: AttachmentsSeen, wantOrder: []int{1, 2}, wantImage: ""},
+		{name: "deferred", mode: AttachmentsDeferred, wantOrder: []int{2, 1}, wantImage: "https://example.com/thumb.png"},
+		{name: "two-pass", mode: AttachmentsTwoPass, wantOrder: []int{1, 2}, wantImage: "https://example.com/thumb.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser().WithAttachmentMode(tt.mode)
+			posts := readStream(t, parser.NewStream(strings.NewReader(streamXML)))
+
+			if len(posts) != len(tt.wantOrder) {
+				t.Fatalf("expected %d posts, got %d", len(tt.wantOrder), len(posts))
+			}
+			for i, id := range tt.wantOrder {
+				if posts[i].ID != id {
+					t.Errorf("posts[%d].ID = %d, want %d", i, posts[i].ID, id)
+				}
+			}
+			for _, post := range posts {
+				if post.ID == 1 && post.FeaturedImage != tt.wantImage {
+					t.Errorf("expected featured image %q, got %q", tt.wantImage, post.FeaturedImage)
+				}
+			}
+		})
+	}
+}
+
+func TestStream_DeferredParentAttachment(t *testing.T) {
+	// Post 2 has no _thumbnail_id, and an attachment uploaded to it comes later
+	xml := strings.Replace(streamXML, "<wp:post_id>10</wp:post_id>",
+		"<wp:post_id>10</wp:post_id>\n\t\t<wp:post_parent>2</wp:post_parent>", 1)
+
+	posts, err := Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if posts[1].ID != 2 || posts[1].FeaturedImage != "https://example.com/thumb.png" {
+		t.Fatalf("Parse() post %d featured image = %q", posts[1].ID, posts[1].FeaturedImage)
+	}
+
+	for mode, want := range map[AttachmentMode]string{
+		AttachmentsDeferred: "",
+		AttachmentsTwoPass:  "https://example.com/thumb.png",
+	} {
+		for _, post := range readStream(t, NewParser().WithAttachmentMode(mode).NewStream(strings.NewReader(xml))) {
+			if post.ID == 2 && post.FeaturedImage != want {
+				t.Errorf("mode %d: post 2 featured image = %q, want %q", mode, post.FeaturedImage, want)
+			}
+		}
+	}
+}
+
+func TestStream_MatchesParse(t *testing.T) {
+	want, err := Parse(strings.NewReader(streamXML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	parser := NewParser().WithAttachmentMode(AttachmentsTwoPass)
+	got := readStream(t, parser.NewStream(bytes.NewReader([]byte(streamXML))))
+
+	if len(got) != len(want) {
+		t.Fatalf("stream returned %d posts, Parse returned %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].FeaturedImage != want[i].FeaturedImage {
+			t.Errorf("post %d differs: stream %+v, Parse %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStream_TwoPassRequiresSeeker(t *testing.T) {
+	parser := NewParser().WithAttachmentMode(AttachmentsTwoPass)
+	stream := parser.NewStream(io.MultiReader(strings.NewReader(streamXML)))
+
+	if _, err := stream.Next(); err == nil || err == io.EOF {
+		t.Fatalf("expected error for non-seekable reader, got %v", err)
+	}
+}
+
+func TestStream_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+	}{
+		{name: "not xml", xml: `not xml at all`},
+		{name: "invalid root", xml: `<?xml version="1.0"?><notrss><channel></channel></notrss>`},
+		{name: "truncated", xml: `<rss><channel><item><title>Cut`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewParser().NewStream(strings.NewReader(tt.xml))
+			_, err := stream.Next()
+			if err == nil || err == io.EOF {
+				t.Fatalf("expected parse error, got %v", err)
+			}
+			if _, again := stream.Next(); again != err {
+				t.Errorf("expected sticky error %v, got %v", err, again)
+			}
+		})
+	}
+}
+
+func TestParser_All(t *testing.T) {
+	var ids []int
+	for post, err := range NewParser().All(context.Background(), strings.NewReader(streamXML)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		ids = append(ids, post.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("expected posts [1 2], got %v", ids)
+	}
+}
+
+func TestParser_All_BreakEarly(t *testing.T) {
+	count := 0
+	for _, err := range All(context.Background(), strings.NewReader(streamXML)) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("expected loop to stop after 1 post, got %d", count)
+	}
+}
+
+func TestParser_All_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var errs []error
+	for post, err := range All(ctx, strings.NewReader(streamXML)) {
+		if err == nil {
+			t.Errorf("unexpected post %d after cancellation", post.ID)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] != context.Canceled {
+		t.Errorf("expected a single context.Canceled error, got %v", errs)
+	}
+}
+
+func TestParser_All_ItemErrors(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title><![CDATA[Broken]]></title>
+		<wp:post_id>not-a-number</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Fine]]></title>
+		<wp:post_id>7</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+	var ids []int
+	var errs []error
+	for post, err := range All(context.Background(), strings.NewReader(xml)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids = append(ids, post.ID)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 item error, got %v", errs)
+	}
+	if len(ids) != 1 || ids[0] != 7 {
+		t.Errorf("expected iteration to continue to post 7, got %v", ids)
+	}
+
+	// Parse still rejects the whole document
+	if _, err := Parse(strings.NewReader(xml)); err == nil {
+		t.Error("expected Parse() to fail on the malformed item")
+	}
+}
+
+func TestParser_All_DocumentError(t *testing.T) {
+	var errs []error
+	for _, err := range All(context.Background(), strings.NewReader(`<rss><channel><item><title>Cut`)) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] == nil {
+		t.Errorf("expected a single document error, got %v", errs)
+	}
+}
@@ -0,0 +1,212 @@
+package wxr
+
+import "strings"
+
+// Term is a taxonomy term, such as a category or a tag.
+// Terms assigned to a post carry the fields of their channel-level definition
+// (wp:category, wp:tag or wp:term) when the export declares one.
+type Term struct {
+	// ID is the WordPress term ID, or 0 if the term is not declared at the channel level.
+	ID int
+
+	// Taxonomy is the taxonomy the term belongs to, such as "category" or "post_tag".
+	Taxonomy string
+
+	// Name is the display name of the term.
+	Name string
+
+	// Slug is the URL-friendly term name (the nicename).
+	Slug string
+
+	// Parent is the slug of the parent term, or empty for a top-level term.
+	Parent string
+
+	// ParentID is the ID of the parent term, or 0 for a top-level term.
+	ParentID int
+
+	// Description is the term description.
+	Description string
+
+	// Meta contains the term meta fields as key-value pairs.
+	Meta map[string]string
+}
+
+// Taxonomy holds the terms of one taxonomy declared at the channel level of an export.
+type Taxonomy struct {
+	// Name is the taxonomy name, such as "category", "post_tag" or "product_cat".
+	Name string
+
+	// Terms are the terms of the taxonomy in declaration order.
+	Terms []Term
+
+	bySlug map[string]int
+}
+
+// TermNode is a term together with its child terms.
+type TermNode struct {
+	Term
+
+	// Children are the terms whose parent is this term, in declaration order.
+	Children []*TermNode
+}
+
+// Term returns the term with the given slug.
+func (t *Taxonomy) Term(slug string) (Term, bool) {
+	i, ok := t.bySlug[slug]
+	if !ok {
+		return Term{}, false
+	}
+	return t.Terms[i], true
+}
+
+// TermByName returns the first term with the given display name, ignoring case.
+func (t *Taxonomy) TermByName(name string) (Term, bool) {
+	for _, term := range t.Terms {
+		if strings.EqualFold(term.Name, name) {
+			return term, true
+		}
+	}
+	return Term{}, false
+}
+
+// Path returns the term with the given slug preceded by its ancestors, root first,
+// such as News > Politics > Elections. It returns nil if the slug is unknown.
+func (t *Taxonomy) Path(slug string) []Term {
+	var path []Term
+	seen := make(map[string]bool)
+	for slug != "" && !seen[slug] {
+		term, ok := t.Term(slug)
+		if !ok {
+			break
+		}
+		seen[slug] = true
+		path = append([]Term{term}, path...)
+		slug = term.Parent
+	}
+	return path
+}
+
+// Children returns the direct child terms of the term with the given slug.
+// Use an empty slug to get the top-level terms.
+func (t *Taxonomy) Children(slug string) []Term {
+	var children []Term
+	for _, term := range t.Terms {
+		if t.parentSlug(term) == slug {
+			children = append(children, term)
+		}
+	}
+	return children
+}
+
+// Tree arranges the terms into their parent/child hierarchy and returns the
+// top-level terms. A term whose parent is not declared is treated as top-level.
+func (t *Taxonomy) Tree() []*TermNode {
+	nodes := make(map[string]*TermNode, len(t.Terms))
+	for _, term := range t.Terms {
+		nodes[term.Slug] = &TermNode{Term: term}
+	}
+
+	roots := make([]*TermNode, 0)
+	for _, term := range t.Terms {
+		node := nodes[term.Slug]
+		if parent := t.parentSlug(term); parent != "" {
+			nodes[parent].Children = append(nodes[parent].Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// parentSlug returns the slug of a term's parent, or empty if the term is top-level,
+// its parent is not declared, or its ancestry loops back to itself.
+func (t *Taxonomy) parentSlug(term Term) string {
+	if _, ok := t.bySlug[term.Parent]; !ok {
+		return ""
+	}
+	for slug, steps := term.Parent, 0; slug != "" && steps <= len(t.Terms); steps++ {
+		if slug == term.Slug {
+			return ""
+		}
+		parent, ok := t.Term(slug)
+		if !ok {
+			break
+		}
+		slug = parent.Parent
+	}
+	return term.Parent
+}
+
+// add appends a term, ignoring terms without a slug and repeated slugs.
+func (t *Taxonomy) add(term Term) {
+	if term.Slug == "" {
+		return
+	}
+	if _, dup := t.bySlug[term.Slug]; dup {
+		return
+	}
+	t.bySlug[term.Slug] = len(t.Terms)
+	t.Terms = append(t.Terms, term)
+}
+
+// buildTaxonomies builds the taxonomies declared by the channel's wp:category,
+// wp:tag and wp:term elements, keyed by taxonomy name.
+func buildTaxonomies(ch channel) map[string]*Taxonomy {
+	taxonomies := make(map[string]*Taxonomy)
+	taxonomy := func(name string) *Taxonomy {
+		t, ok := taxonomies[name]
+		if !ok {
+			t = &Taxonomy{Name: name, bySlug: make(map[string]int)}
+			taxonomies[name] = t
+		}
+		return t
+	}
+
+	for _, c := range ch.Categories {
+		taxonomy("category").add(Term{
+			ID:          c.TermID,
+			Taxonomy:    "category",
+			Name:        strings.TrimSpace(c.Name),
+			Slug:        strings.TrimSpace(c.NiceName),
+			Parent:      strings.TrimSpace(c.Parent),
+			Description: c.Description,
+			Meta:        metaMap(c.Meta),
+		})
+	}
+	for _, tag := range ch.Tags {
+		taxonomy("post_tag").add(Term{
+			ID:          tag.TermID,
+			Taxonomy:    "post_tag",
+			Name:        strings.TrimSpace(tag.Name),
+			Slug:        strings.TrimSpace(tag.Slug),
+			Description: tag.Description,
+			Meta:        metaMap(tag.Meta),
+		})
+	}
+	for _, term := range ch.Terms {
+		name := strings.TrimSpace(term.Taxonomy)
+		if name == "" {
+			continue
+		}
+		taxonomy(name).add(Term{
+			ID:          term.TermID,
+			Taxonomy:    name,
+			Name:        strings.TrimSpace(term.Name),
+			Slug:        strings.TrimSpace(term.Slug),
+			Parent:      strings.TrimSpace(term.Parent),
+			Description: term.Description,
+			Meta:        metaMap(term.Meta),
+		})
+	}
+
+	// Resolve parent IDs now that every term of each taxonomy is known
+	for _, t := range taxonomies {
+		for i := range t.Terms {
+			if parent, ok := t.Term(t.Terms[i].Parent); ok {
+				t.Terms[i].ParentID = parent.ID
+			}
+		}
+	}
+
+	return taxonomies
+}
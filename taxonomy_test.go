@@ -0,0 +1,240 @@
+package wxr
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const taxonomyXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Test Site</title>
+	<wp:category>
+		<wp:term_id>1</wp:term_id>
+		<wp:category_nicename><![CDATA[news]]></wp:category_nicename>
+		<wp:category_parent><![CDATA[]]></wp:category_parent>
+		<wp:cat_name><![CDATA[News]]></wp:cat_name>
+	</wp:category>
+	<wp:category>
+		<wp:term_id>2</wp:term_id>
+		<wp:category_nicename><![CDATA[politics]]></wp:category_nicename>
+		<wp:category_parent><![CDATA[news]]></wp:category_parent>
+		<wp:cat_name><![CDATA[Politics]]></wp:cat_name>
+		<wp:category_description><![CDATA[Government and policy]]></wp:category_description>
+	</wp:category>
+	<wp:category>
+		<wp:term_id>3</wp:term_id>
+		<wp:category_nicename><![CDATA[elections]]></wp:category_nicename>
+		<wp:category_parent><![CDATA[politics]]></wp:category_parent>
+		<wp:cat_name><![CDATA[Elections]]></wp:cat_name>
+		<wp:termmeta>
+			<wp:meta_key><![CDATA[color]]></wp:meta_key>
+			<wp:meta_value><![CDATA[red]]></wp:meta_value>
+		</wp:termmeta>
+	</wp:category>
+	<wp:category>
+		<wp:term_id>4</wp:term_id>
+		<wp:category_nicename><![CDATA[sports]]></wp:category_nicename>
+		<wp:cat_name><![CDATA[Sports]]></wp:cat_name>
+	</wp:category>
+	<wp:tag>
+		<wp:term_id>10</wp:term_id>
+		<wp:tag_slug><![CDATA[vote]]></wp:tag_slug>
+		<wp:tag_name><![CDATA[Vote]]></wp:tag_name>
+	</wp:tag>
+	<wp:term>
+		<wp:term_id>20</wp:term_id>
+		<wp:term_taxonomy><![CDATA[series]]></wp:term_taxonomy>
+		<wp:term_slug><![CDATA[runoff]]></wp:term_slug>
+		<wp:term_parent><![CDATA[]]></wp:term_parent>
+		<wp:term_name><![CDATA[Runoff]]></wp:term_name>
+	</wp:term>
+	<item>
+		<title><![CDATA[Ballots Counted]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<category domain="category" nicename="elections"><![CDATA[Elections]]></category>
+		<category domain="post_tag" nicename="vote"><![CDATA[Vote]]></category>
+		<category domain="post_tag" nicename="undeclared"><![CDATA[Undeclared]]></category>
+	</item>
+</channel>
+</rss>`
+
+func TestParseDocument_Taxonomies(t *testing.T) {
+	doc, err := ParseDocument(context.Background(), strings.NewReader(taxonomyXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+
+	categories, ok := doc.Taxonomies["category"]
+	if !ok || len(categories.Terms) != 4 {
+		t.Fatalf("expected 4 categories, got %+v", doc.Taxonomies["category"])
+	}
+	if _, ok := doc.Taxonomies["post_tag"]; !ok {
+		t.Error("expected post_tag taxonomy")
+	}
+	if series, ok := doc.Taxonomies["series"]; !ok || series.Terms[0].ID != 20 {
+		t.Errorf("expected custom taxonomy from wp:term, got %+v", series)
+	}
+
+	elections, ok := categories.Term("elections")
+	if !ok {
+		t.Fatal("expected elections category")
+	}
+	if elections.ID != 3 || elections.Parent != "politics" || elections.ParentID != 2 || elections.Meta["color"] != "red" {
+		t.Errorf("unexpected elections term: %+v", elections)
+	}
+
+	var names []string
+	for _, term := range categories.Path("elections") {
+		names = append(names, term.Name)
+	}
+	if strings.Join(names, " > ") != "News > Politics > Elections" {
+		t.Errorf("unexpected path %v", names)
+	}
+
+	if children := categories.Children("news"); len(children) != 1 || children[0].Slug != "politics" {
+		t.Errorf("unexpected children of news: %+v", children)
+	}
+
+	tree := categories.Tree()
+	if len(tree) != 2 || tree[0].Slug != "news" || tree[1].Slug != "sports" {
+		t.Fatalf("unexpected roots: %+v", tree)
+	}
+	if len(tree[0].Children) != 1 || len(tree[0].Children[0].Children) != 1 || tree[0].Children[0].Children[0].Slug != "elections" {
+		t.Errorf("unexpected hierarchy under news: %+v", tree[0].Children)
+	}
+}
+
+func TestParseDocument_PostTerms(t *testing.T) {
+	doc, err := ParseDocument(context.Background(), strings.NewReader(taxonomyXML))
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if len(doc.Posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(doc.Posts))
+	}
+
+	terms := doc.Posts[0].Terms
+	if len(terms["category"]) != 1 || terms["category"][0].ID != 3 || terms["category"][0].ParentID != 2 {
+		t.Errorf("expected resolved elections category, got %+v", terms["category"])
+	}
+	tags := terms["post_tag"]
+	if len(tags) != 2 || tags[0].ID != 10 || tags[0].Slug != "vote" {
+		t.Fatalf("expected resolved vote tag, got %+v", tags)
+	}
+	if tags[1].ID != 0 || tags[1].Name != "Undeclared" || tags[1].Slug != "undeclared" {
+		t.Errorf("expected undeclared tag kept from the item, got %+v", tags[1])
+	}
+}
+
+func TestStream_Taxonomies(t *testing.T) {
+	stream := NewParser().NewStream(strings.NewReader(taxonomyXML))
+	post, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if post.Terms["category"][0].ID != 3 {
+		t.Errorf("expected resolved category in stream, got %+v", post.Terms["category"])
+	}
+	if len(stream.Taxonomies()["category"].Terms) != 4 {
+		t.Errorf("expected stream to expose 4 categories, got %+v", stream.Taxonomies())
+	}
+}
+
+func TestTaxonomy_Cycles(t *testing.T) {
+	taxonomy := &Taxonomy{Name: "category", bySlug: make(map[string]int)}
+	taxonomy.add(Term{Slug: "a", Parent: "b"})
+	taxonomy.add(Term{Slug: "b", Parent: "a"})
+	taxonomy.add(Term{Slug: "c", Parent: "a"})
+	taxonomy.add(Term{Slug: "d", Parent: "d"})
+
+	roots := taxonomy.Tree()
+	var slugs []string
+	for _, root := range roots {
+		slugs = append(slugs, root.Slug)
+	}
+	if strings.Join(slugs, ",") != "a,b,d" {
+		t.Fatalf("expected looping terms to become roots, got %v", slugs)
+	}
+	if len(roots[0].Children) != 1 || roots[0].Children[0].Slug != "c" {
+		t.Errorf("expected c under a, got %+v", roots[0].Children)
+	}
+	if path := taxonomy.Path("c"); len(path) != 3 {
+		t.Errorf("expected path to stop at the loop, got %+v", path)
+	}
+}
+
+func TestParse_CustomTaxonomyTerms(t *testing.T) {
+	xmlData := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:term>
+		<wp:term_id>30</wp:term_id>
+		<wp:term_taxonomy><![CDATA[product_cat]]></wp:term_taxonomy>
+		<wp:term_slug><![CDATA[shoes]]></wp:term_slug>
+		<wp:term_name><![CDATA[Shoes]]></wp:term_name>
+	</wp:term>
+	<item>
+		<title><![CDATA[Trail Runner]]></title>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<wp:post_id>7</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<category domain="category" nicename="gear"><![CDATA[Gear]]></category>
+		<category domain="product_cat" nicename="shoes"><![CDATA[Shoes]]></category>
+		<category domain="series" nicename="field-tests"><![CDATA[Field Tests]]></category>
+		<category domain="post_format" nicename="post-format-video"><![CDATA[Video]]></category>
+		<category domain="language" nicename="pt"><![CDATA[Português]]></category>
+	</item>
+</channel>
+</rss>`
+
+	posts, err := Parse(strings.NewReader(xmlData))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+	post := posts[0]
+
+	if len(post.Terms) != 5 {
+		t.Errorf("expected 5 taxonomies, got %+v", post.Terms)
+	}
+	tests := []struct {
+		taxonomy, name, slug string
+		id                   int
+	}{
+		{"category", "Gear", "gear", 0},
+		{"product_cat", "Shoes", "shoes", 30},
+		{"series", "Field Tests", "field-tests", 0},
+		{"post_format", "Video", "post-format-video", 0},
+		{"language", "Português", "pt", 0},
+	}
+	for _, tt := range tests {
+		terms := post.Terms[tt.taxonomy]
+		if len(terms) != 1 {
+			t.Errorf("Terms[%q] = %+v, want one term", tt.taxonomy, terms)
+			continue
+		}
+		if got := terms[0]; got.Name != tt.name || got.Slug != tt.slug || got.ID != tt.id || got.Taxonomy != tt.taxonomy {
+			t.Errorf("Terms[%q] = %+v, want name %q slug %q id %d", tt.taxonomy, got, tt.name, tt.slug, tt.id)
+		}
+	}
+
+	// Custom taxonomies do not leak into the category and tag name lists.
+	if len(post.Categories) != 1 || post.Categories[0] != "Gear" {
+		t.Errorf("Categories = %v, want [Gear]", post.Categories)
+	}
+	if len(post.Tags) != 0 {
+		t.Errorf("Tags = %v, want none", post.Tags)
+	}
+}
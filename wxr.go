@@ -3,9 +3,9 @@ package wxr
 import (
 	"context"
 	"encoding/xml"
-	"fmt"
 	"io"
 	"log"
+	"strings"
 )
 
 // Parser provides configurable parsing of WordPress WXR export files.
@@ -19,33 +19,45 @@ type Parser struct {
 	categoryExt      *CategoryExtractor
 	metaExt          *MetaExtractor
 	featuredImageExt *FeaturedImageExtractor
+	commentExt       *CommentExtractor
+	attachmentMode   AttachmentMode
+	autoP            bool
+	recovery         bool
+	charsetReader    func(charset string, input io.Reader) (io.Reader, error)
+	mojibake         bool
+	mojibakePasses   int
+	plainText        bool
 }
 
 // NewParser creates a new Parser with the default no-op logger.
 func NewParser() *Parser {
 	return &Parser{
-		logger:          &noOpLogger{},
-		filter:          NewDefaultFilter(),
-		authorExt:       &AuthorExtractor{},
-		excerptExt:      &ExcerptExtractor{},
-		dateExt:         &DateExtractor{},
-		modifiedDateExt: &ModifiedDateExtractor{},
-		categoryExt:     &CategoryExtractor{},
-		metaExt:         &MetaExtractor{},
+		logger:           &noOpLogger{},
+		filter:           NewDefaultFilter(),
+		authorExt:        &AuthorExtractor{},
+		excerptExt:       &ExcerptExtractor{},
+		dateExt:          &DateExtractor{},
+		modifiedDateExt:  &ModifiedDateExtractor{},
+		categoryExt:      &CategoryExtractor{},
+		metaExt:          &MetaExtractor{},
+		featuredImageExt: &FeaturedImageExtractor{},
+		commentExt:       &CommentExtractor{},
 	}
 }
 
 // NewParserWithLogger creates a new Parser with a custom logger.
 func NewParserWithLogger(logger Logger) *Parser {
 	return &Parser{
-		logger:          logger,
-		filter:          NewDefaultFilter(),
-		authorExt:       &AuthorExtractor{},
-		excerptExt:      &ExcerptExtractor{},
-		dateExt:         &DateExtractor{},
-		modifiedDateExt: &ModifiedDateExtractor{},
-		categoryExt:     &CategoryExtractor{},
-		metaExt:         &MetaExtractor{},
+		logger:           logger,
+		filter:           NewDefaultFilter(),
+		authorExt:        &AuthorExtractor{},
+		excerptExt:       &ExcerptExtractor{},
+		dateExt:          &DateExtractor{},
+		modifiedDateExt:  &ModifiedDateExtractor{},
+		categoryExt:      &CategoryExtractor{},
+		metaExt:          &MetaExtractor{},
+		featuredImageExt: &FeaturedImageExtractor{},
+		commentExt:       &CommentExtractor{},
 	}
 }
 
@@ -56,14 +68,16 @@ func NewParserWithStdLogger(stdLogger *log.Logger) *Parser {
 		return NewParser()
 	}
 	return &Parser{
-		logger:          &stdLoggerAdapter{logger: stdLogger},
-		filter:          NewDefaultFilter(),
-		authorExt:       &AuthorExtractor{},
-		excerptExt:      &ExcerptExtractor{},
-		dateExt:         &DateExtractor{},
-		modifiedDateExt: &ModifiedDateExtractor{},
-		categoryExt:     &CategoryExtractor{},
-		metaExt:         &MetaExtractor{},
+		logger:           &stdLoggerAdapter{logger: stdLogger},
+		filter:           NewDefaultFilter(),
+		authorExt:        &AuthorExtractor{},
+		excerptExt:       &ExcerptExtractor{},
+		dateExt:          &DateExtractor{},
+		modifiedDateExt:  &ModifiedDateExtractor{},
+		categoryExt:      &CategoryExtractor{},
+		metaExt:          &MetaExtractor{},
+		featuredImageExt: &FeaturedImageExtractor{},
+		commentExt:       &CommentExtractor{},
 	}
 }
 
@@ -132,59 +146,137 @@ func (p *Parser) WithFeaturedImageExtractor(extractor *FeaturedImageExtractor) *
 	return p
 }
 
-// decodeXML decodes and validates the WXR XML document.
-func (p *Parser) decodeXML(r io.Reader) (*wxr, error) {
-	var wxrDoc wxr
-	decoder := xml.NewDecoder(r)
+// WithAttachmentMode sets how streams created by NewStream resolve featured images
+// that reference attachments appearing later in the document.
+// Parse always sees the whole document and is not affected.
+// Returns the parser for method chaining.
+func (p *Parser) WithAttachmentMode(mode AttachmentMode) *Parser {
+	p.attachmentMode = mode
+	return p
+}
 
-	// Handle CDATA sections properly
-	decoder.Strict = false
+// WithAutoP runs AutoP on the content of posts written with the classic editor,
+// as WordPress does when it displays them, so that ContentRendered has <p> and
+// <br /> tags instead of bare line breaks. Block editor content is left as is.
+// Returns the parser for method chaining.
+func (p *Parser) WithAutoP(enabled bool) *Parser {
+	p.autoP = enabled
+	return p
+}
 
-	if err := decoder.Decode(&wxrDoc); err != nil {
-		return nil, fmt.Errorf("wxr: failed to parse WXR XML: %w", err)
-	}
+// WithRecovery enables recovery mode for exports that are malformed, such as
+// files truncated by a PHP timeout. In recovery mode the parser removes the
+// characters XML 1.0 does not allow and replaces invalid UTF-8, drops an
+// element that cannot be decoded instead of its whole item, skips items with
+// broken XML and resumes at the next <item>, and accepts a document that ends
+// without its closing tags. Each repair and each lost item is described by a
+// Diagnostic in Document.Diagnostics or Stream.Diagnostics, and logged.
+// Returns the parser for method chaining.
+func (p *Parser) WithRecovery(enabled bool) *Parser {
+	p.recovery = enabled
+	return p
+}
 
-	// Validate that we actually got an RSS document
-	// XMLName.Local will be empty if the decoder didn't find a matching root element
-	if wxrDoc.XMLName.Local != "rss" {
-		return nil, fmt.Errorf("wxr: invalid WXR XML: root element is not <rss> (got %q)", wxrDoc.XMLName.Local)
-	}
+// WithCharsetReader sets a function that converts documents declaring an encoding
+// NewCharsetReader does not support to UTF-8, such as
+// golang.org/x/net/html/charset.NewReaderLabel. Documents in the built-in
+// charsets are converted without it.
+// Returns the parser for method chaining.
+func (p *Parser) WithCharsetReader(charsetReader func(charset string, input io.Reader) (io.Reader, error)) *Parser {
+	p.charsetReader = charsetReader
+	return p
+}
 
-	return &wxrDoc, nil
+// WithMojibakeRepair runs RepairMojibake on the titles, content, excerpts,
+// categories, comments and meta values of items, and on the site title and
+// description, authors and terms of the channel, so that exports of sites that
+// went through a latin1 to utf8 migration read "São Paulo" instead of
+// "SÃ£o Paulo". Each item or channel element repaired is described by a
+// Diagnostic in Document.Diagnostics or Stream.Diagnostics, and logged.
+// Returns the parser for method chaining.
+func (p *Parser) WithMojibakeRepair(enabled bool) *Parser {
+	p.mojibake = enabled
+	return p
 }
 
-// buildAuthorMap builds a map from author login to display name.
-func (p *Parser) buildAuthorMap(ch channel) map[string]string {
-	authorMap := make(map[string]string)
-	for _, author := range ch.Authors {
-		authorMap[author.Login] = author.DisplayName
-	}
-	return authorMap
+// WithMojibakePasses sets how many layers of double encoding WithMojibakeRepair
+// undoes in each field, 1 by default; see RepairMojibakeN. Exports of sites
+// converted more than once need 2 or more, at a higher risk of changing text
+// that was correct. Returns the parser for method chaining.
+func (p *Parser) WithMojibakePasses(passes int) *Parser {
+	p.mojibakePasses = passes
+	return p
 }
 
-// transformItem converts an item to a Post using the configured extractors.
-func (p *Parser) transformItem(item *item, attachmentIndex *AttachmentIndex) Post {
-	// Initialize extractors if needed
-	if p.categoryExt == nil {
-		p.categoryExt = &CategoryExtractor{}
-	}
-	if p.modifiedDateExt == nil {
-		p.modifiedDateExt = &ModifiedDateExtractor{}
+// WithPlainText sets Post.TitlePlain and Post.CategoriesPlain to PlainText
+// versions of the title and category names, which WordPress stores
+// entity-escaped, such as "News &amp; Events".
+// Returns the parser for method chaining.
+func (p *Parser) WithPlainText(enabled bool) *Parser {
+	p.plainText = enabled
+	return p
+}
+
+// decodeXML decodes and validates the WXR XML document.
+// The document is read with the same token-based reader used by Stream and
+// all items are collected in memory.
+func (p *Parser) decodeXML(r io.Reader) (*wxr, error) {
+	src := newItemReader(r, p)
+	var items []item
+	for {
+		it, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *it)
 	}
-	if p.metaExt == nil {
-		p.metaExt = &MetaExtractor{}
+
+	wxrDoc := &wxr{
+		XMLName: xml.Name{Local: "rss"},
+		Channel: src.channel,
 	}
-	if p.featuredImageExt == nil {
-		p.featuredImageExt = &FeaturedImageExtractor{attachmentIndex: attachmentIndex}
-	} else {
-		p.featuredImageExt.attachmentIndex = attachmentIndex
+	wxrDoc.Channel.Items = items
+	return wxrDoc, nil
+}
+
+// channelIndex holds the channel-level lookups used while transforming items.
+type channelIndex struct {
+	attachments *AttachmentIndex
+	taxonomies  map[string]*Taxonomy
+	authors     []Author
+	byLogin     map[string]Author
+}
+
+// buildChannelIndex builds the lookups for a fully decoded channel.
+func buildChannelIndex(ch channel) *channelIndex {
+	authors := buildAuthors(ch)
+	return &channelIndex{
+		attachments: buildAttachmentIndex(ch),
+		taxonomies:  buildTaxonomies(ch),
+		authors:     authors,
+		byLogin:     authorsByLogin(authors),
 	}
+}
 
-	categories := p.categoryExt.ExtractCategories(item)
+// transformItem converts an item to a Post using the configured extractors.
+func (p *Parser) transformItem(item *item, index *channelIndex) Post {
+	// The parser may be parsing several documents at once, so the extractors
+	// that look things up in the document are copied rather than updated
+	authorExt := *p.authorExt
+	authorExt.authors = index.byLogin
+	categoryExt := *p.categoryExt
+	categoryExt.taxonomies = index.taxonomies
+	featuredImageExt := *p.featuredImageExt
+	featuredImageExt.attachmentIndex = index.attachments
+
+	categories := categoryExt.ExtractCategories(item)
 	if categories == nil {
 		categories = []string{}
 	}
-	tags := p.categoryExt.ExtractTags(item)
+	tags := categoryExt.ExtractTags(item)
 	if tags == nil {
 		tags = []string{}
 	}
@@ -193,106 +285,130 @@ func (p *Parser) transformItem(item *item, attachmentIndex *AttachmentIndex) Pos
 		meta = make(map[string]string)
 	}
 
-	return Post{
-		ID:              item.PostID,
-		TitleRendered:   item.Title,
-		ContentRendered: item.ContentEncoded,
-		Excerpt:         p.excerptExt.Extract(item),
-		Slug:            item.PostName,
-		Link:            item.Link, // Canonical permalink from XML
-		Author:          p.authorExt.Extract(item),
-		Date:            p.dateExt.Extract(item),
-		ModifiedDate:    p.modifiedDateExt.Extract(item),
-		Categories:      categories,
-		Tags:            tags,
-		GUID:            item.GUID,
-		ParentID:        item.PostParent,
-		Meta:            meta,
-		FeaturedImage:   p.featuredImageExt.Extract(item),
+	content := item.ContentEncoded
+	if p.autoP && !hasBlocks(content) {
+		content = AutoP(content, true)
 	}
-}
-
-// Parse parses a WordPress WXR XML export file and converts it into Post instances.
-// It filters for published posts only (post_type="post" and status="publish").
-// Returns an error if the XML is malformed or cannot be read.
-//
-// The parser handles:
-//   - Attachment URL resolution for featured images
-//   - Author name resolution from meta fields or dc:creator
-//   - Date normalization to RFC3339 format
-//   - Excerpt fallback to subtitle meta field
-//   - Featured image resolution from meta fields or attachments
-func (p *Parser) Parse(r io.Reader) ([]Post, error) {
-	p.logger.Printf("Starting WXR parsing")
 
-	wxrDoc, err := p.decodeXML(r)
-	if err != nil {
-		return nil, err
+	var titlePlain string
+	var categoriesPlain []string
+	if p.plainText {
+		titlePlain = PlainText(item.Title)
+		categoriesPlain = make([]string, len(categories))
+		for i, name := range categories {
+			categoriesPlain[i] = PlainText(name)
+		}
 	}
 
-	p.logger.Printf("Parsed WXR document, found %d items", len(wxrDoc.Channel.Items))
-
-	posts := make([]Post, 0)
-	skippedCount := 0
-	skippedByType := make(map[string]int)
-	skippedByStatus := make(map[string]int)
-
-	// Build attachment lookups (ID -> URL) and parent->attachments map
-	attachmentIndex := buildAttachmentIndex(wxrDoc.Channel)
+	return Post{
+		ID:                item.PostID,
+		TitleRendered:     item.Title,
+		TitlePlain:        titlePlain,
+		ContentRendered:   content,
+		ContentRaw:        item.ContentEncoded,
+		Excerpt:           p.excerptExt.Extract(item),
+		ExcerptRaw:        item.ExcerptEncoded,
+		RSSDescription:    item.Description,
+		Slug:              item.PostName,
+		Type:              item.PostType,
+		Status:            item.Status,
+		Link:              item.Link, // Canonical permalink from XML
+		Author:            authorExt.Extract(item),
+		AuthorLogin:       strings.TrimSpace(item.DCCreator),
+		Date:              p.dateExt.Extract(item),
+		LocalDate:         item.PostDate,
+		ModifiedDate:      p.modifiedDateExt.Extract(item),
+		LocalModifiedDate: item.PostModified,
+		Categories:        categories,
+		CategoriesPlain:   categoriesPlain,
+		Tags:              tags,
+		Terms:             categoryExt.ExtractTerms(item),
+		GUID:              item.GUID,
+		ParentID:          item.PostParent,
+		MenuOrder:         item.MenuOrder,
+		PageTemplate:      getMetaValue(item.PostMeta, "_wp_page_template"),
+		Meta:              meta,
+		MetaEntries:       metaEntries(item.PostMeta),
+		FeaturedImage:     featuredImageExt.Extract(item),
+		Comments:          p.commentExt.Extract(item),
+		Extra:             item.Extra,
+	}
+}
 
-	// Build author lookup map (currently unused but kept for potential future use)
-	_ = p.buildAuthorMap(wxrDoc.Channel)
+// includeItem reports whether an item should become a Post, recording the reason
+// in stats when it is skipped.
+func (p *Parser) includeItem(item *item, stats *parseStats) bool {
+	// Filter: only include items matching the configured filter
+	if !p.filter.ShouldInclude(Item{item: item}) {
+		stats.skippedByFilter[item.PostType+"/"+item.Status]++
+		stats.skipped++
+		return false
+	}
 
-	for i := range wxrDoc.Channel.Items {
-		item := &wxrDoc.Channel.Items[i]
+	// Validate essential fields
+	if item.PostID == 0 {
+		p.logger.Printf("Skipping item with missing post_id")
+		stats.skipped++
+		return false
+	}
 
-		// Filter: only include posts matching filter criteria
-		// Track skipped items separately by type and status to match original behavior
-		if item.PostType != "post" {
-			skippedByType[item.PostType]++
-			skippedCount++
-			continue
-		}
+	if item.Title == "" && item.ContentEncoded == "" && item.ExcerptEncoded == "" {
+		p.logger.Printf("Skipping post %d: missing title, content, and excerpt", item.PostID)
+		stats.skipped++
+		return false
+	}
 
-		if item.Status != "publish" {
-			skippedByStatus[item.Status]++
-			skippedCount++
-			continue
-		}
+	return true
+}
 
-		// Validate essential fields
-		if item.PostID == 0 {
-			p.logger.Printf("Skipping item with missing post_id")
-			skippedCount++
-			continue
-		}
+// buildPost transforms an included item into a Post and warns about malformed input.
+func (p *Parser) buildPost(item *item, index *channelIndex) Post {
+	post := p.transformItem(item, index)
 
-		if item.Title == "" && item.ContentEncoded == "" && item.ExcerptEncoded == "" {
-			p.logger.Printf("Skipping post %d: missing title, content, and excerpt", item.PostID)
-			skippedCount++
-			continue
-		}
+	// Log warning if both Link and Slug are missing (malformed input)
+	if post.Link == "" && post.Slug == "" {
+		p.logger.Printf("Warning: Post %d has neither Link nor Slug - URL construction may fail", post.ID)
+	}
 
-		// Transform item to Post
-		post := p.transformItem(item, attachmentIndex)
+	return post
+}
 
-		// Log warning if both Link and Slug are missing (malformed input)
-		if post.Link == "" && post.Slug == "" {
-			p.logger.Printf("Warning: Post %d has neither Link nor Slug - URL construction may fail", post.ID)
-		}
+// parseStats tracks how many items were turned into posts or skipped during a parse.
+type parseStats struct {
+	posts           int
+	skipped         int
+	skippedByFilter map[string]int // keyed by "post_type/status"
+}
 
-		posts = append(posts, post)
+func newParseStats() *parseStats {
+	return &parseStats{
+		skippedByFilter: make(map[string]int),
 	}
+}
 
-	p.logger.Printf("WXR parsing complete: %d posts extracted, %d items skipped", len(posts), skippedCount)
-	if len(skippedByType) > 0 {
-		p.logger.Printf("Skipped by type: %+v", skippedByType)
-	}
-	if len(skippedByStatus) > 0 {
-		p.logger.Printf("Skipped by status: %+v", skippedByStatus)
+// log writes the parse summary to logger.
+func (s *parseStats) log(logger Logger) {
+	logger.Printf("WXR parsing complete: %d posts extracted, %d items skipped", s.posts, s.skipped)
+	if len(s.skippedByFilter) > 0 {
+		logger.Printf("Skipped by filter (post_type/status): %+v", s.skippedByFilter)
 	}
+}
 
-	return posts, nil
+// Parse parses a WordPress WXR XML export file and converts it into Post instances.
+// It includes the items accepted by the parser's Filter, which by default selects
+// published posts only (post_type="post" and status="publish").
+// Returns an error if the XML is malformed or cannot be read.
+//
+// The parser handles:
+//   - Attachment URL resolution for featured images
+//   - Author name resolution from meta fields or the wp:author matching dc:creator
+//   - Date normalization to RFC3339 format
+//   - Excerpt fallback to subtitle meta field
+//   - Featured image resolution from meta fields or attachments
+//
+// Parse holds the whole document in memory; use NewStream for large exports.
+func (p *Parser) Parse(r io.Reader) ([]Post, error) {
+	return p.ParseWithContext(context.Background(), r)
 }
 
 // Parse is a convenience function that parses a WXR file using the default parser.
@@ -320,89 +436,9 @@ func (p *Parser) ParseWithContext(ctx context.Context, r io.Reader) ([]Post, err
 	default:
 	}
 
-	p.logger.Printf("Starting WXR parsing")
-
-	wxrDoc, err := p.decodeXML(r)
-	if err != nil {
+	doc, err := p.ParseDocument(ctx, r)
+	if doc == nil {
 		return nil, err
 	}
-
-	// Check context after decoding
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	p.logger.Printf("Parsed WXR document, found %d items", len(wxrDoc.Channel.Items))
-
-	posts := make([]Post, 0)
-	skippedCount := 0
-	skippedByType := make(map[string]int)
-	skippedByStatus := make(map[string]int)
-
-	// Build attachment lookups (ID -> URL) and parent->attachments map
-	attachmentIndex := buildAttachmentIndex(wxrDoc.Channel)
-
-	// Build author lookup map (currently unused but kept for potential future use)
-	_ = p.buildAuthorMap(wxrDoc.Channel)
-
-	for i := range wxrDoc.Channel.Items {
-		// Check context periodically during parsing
-		select {
-		case <-ctx.Done():
-			p.logger.Printf("Parsing cancelled, returning %d posts parsed so far", len(posts))
-			return posts, ctx.Err()
-		default:
-		}
-
-		item := &wxrDoc.Channel.Items[i]
-
-		// Filter: only include posts matching filter criteria
-		// Track skipped items separately by type and status to match original behavior
-		if item.PostType != "post" {
-			skippedByType[item.PostType]++
-			skippedCount++
-			continue
-		}
-
-		if item.Status != "publish" {
-			skippedByStatus[item.Status]++
-			skippedCount++
-			continue
-		}
-
-		// Validate essential fields
-		if item.PostID == 0 {
-			p.logger.Printf("Skipping item with missing post_id")
-			skippedCount++
-			continue
-		}
-
-		if item.Title == "" && item.ContentEncoded == "" && item.ExcerptEncoded == "" {
-			p.logger.Printf("Skipping post %d: missing title, content, and excerpt", item.PostID)
-			skippedCount++
-			continue
-		}
-
-		// Transform item to Post
-		post := p.transformItem(item, attachmentIndex)
-
-		// Log warning if both Link and Slug are missing (malformed input)
-		if post.Link == "" && post.Slug == "" {
-			p.logger.Printf("Warning: Post %d has neither Link nor Slug - URL construction may fail", post.ID)
-		}
-
-		posts = append(posts, post)
-	}
-
-	p.logger.Printf("WXR parsing complete: %d posts extracted, %d items skipped", len(posts), skippedCount)
-	if len(skippedByType) > 0 {
-		p.logger.Printf("Skipped by type: %+v", skippedByType)
-	}
-	if len(skippedByStatus) > 0 {
-		p.logger.Printf("Skipped by status: %+v", skippedByStatus)
-	}
-
-	return posts, nil
+	return doc.Posts, err
 }
@@ -2,7 +2,9 @@ package wxr
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -647,3 +649,196 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+func TestParse_PagesAndCustomPostTypes(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Test Site</title>
+	<item>
+		<title><![CDATA[About]]></title>
+		<content:encoded><![CDATA[About us]]></content:encoded>
+		<wp:post_id>10</wp:post_id>
+		<wp:menu_order>2</wp:menu_order>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:postmeta>
+			<wp:meta_key><![CDATA[_wp_page_template]]></wp:meta_key>
+			<wp:meta_value><![CDATA[templates/full-width.php]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title><![CDATA[Team]]></title>
+		<content:encoded><![CDATA[Our team]]></content:encoded>
+		<wp:post_id>11</wp:post_id>
+		<wp:post_parent>10</wp:post_parent>
+		<wp:menu_order>1</wp:menu_order>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Blue Mug]]></title>
+		<content:encoded><![CDATA[A mug]]></content:encoded>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_type><![CDATA[product]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Home]]></title>
+		<wp:post_id>13</wp:post_id>
+		<wp:post_type><![CDATA[nav_menu_item]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[photo]]></title>
+		<wp:post_id>14</wp:post_id>
+		<wp:post_type><![CDATA[attachment]]></wp:post_type>
+		<wp:status><![CDATA[inherit]]></wp:status>
+	</item>
+	<item>
+		<title><![CDATA[Hello]]></title>
+		<content:encoded><![CDATA[Hi]]></content:encoded>
+		<wp:post_id>15</wp:post_id>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<wp:status><![CDATA[publish]]></wp:status>
+	</item>
+</channel>
+</rss>`
+
+	parser := NewParser().WithFilter(NewContentFilter())
+	posts, err := parser.Parse(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	byID := make(map[int]Post)
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+	if len(byID) != 4 {
+		t.Fatalf("expected pages, product and post, got %d posts: %+v", len(posts), posts)
+	}
+
+	about := byID[10]
+	if about.Type != "page" || about.MenuOrder != 2 || about.PageTemplate != "templates/full-width.php" {
+		t.Errorf("unexpected page fields: type %q, menu order %d, template %q", about.Type, about.MenuOrder, about.PageTemplate)
+	}
+	if team := byID[11]; team.ParentID != 10 || team.MenuOrder != 1 || team.PageTemplate != "" {
+		t.Errorf("unexpected child page fields: parent %d, menu order %d, template %q", team.ParentID, team.MenuOrder, team.PageTemplate)
+	}
+	if product := byID[12]; product.Type != "product" {
+		t.Errorf("expected custom post type 'product', got %q", product.Type)
+	}
+	if post := byID[15]; post.Type != "post" {
+		t.Errorf("expected post type 'post', got %q", post.Type)
+	}
+}
+
+func TestParseDocument_LegacyNamespaces(t *testing.T) {
+	const legacyXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/%[1]s/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/%[1]s/">
+<channel>
+	<title>Old blog</title>
+	<wp:wxr_version>%[1]s</wp:wxr_version>
+	<wp:base_site_url>https://old.example.com</wp:base_site_url>
+	<wp:author><wp:author_login>joao</wp:author_login><wp:author_display_name><![CDATA[João]]></wp:author_display_name></wp:author>
+	<wp:category><wp:term_id>3</wp:term_id><wp:category_nicename>news</wp:category_nicename><wp:category_parent></wp:category_parent><wp:cat_name><![CDATA[News]]></wp:cat_name></wp:category>
+	<item>
+		<title>Legacy post</title>
+		<dc:creator>joao</dc:creator>
+		<content:encoded><![CDATA[Content]]></content:encoded>
+		<excerpt:encoded><![CDATA[Excerpt]]></excerpt:encoded>
+		<wp:post_id>42</wp:post_id>
+		<wp:post_date>2009-05-01 10:00:00</wp:post_date>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+		<wp:is_sticky>0</wp:is_sticky>
+		<category domain="category" nicename="news"><![CDATA[News]]></category>
+		<wp:postmeta><wp:meta_key>views</wp:meta_key><wp:meta_value>7</wp:meta_value></wp:postmeta>
+	</item>
+</channel>
+</rss>`
+
+	for _, version := range []string{"1.0", "1.1", "1.2"} {
+		t.Run(version, func(t *testing.T) {
+			doc, err := ParseDocument(context.Background(), strings.NewReader(fmt.Sprintf(legacyXML, version)))
+			if err != nil {
+				t.Fatalf("ParseDocument() error = %v", err)
+			}
+			if doc.Site.NamespaceVersion != version {
+				t.Errorf("NamespaceVersion = %q, want %q", doc.Site.NamespaceVersion, version)
+			}
+			if doc.Site.WXRVersion != version || doc.Site.BaseSiteURL != "https://old.example.com" {
+				t.Errorf("Site = %+v", doc.Site)
+			}
+			if len(doc.Authors) != 1 || doc.Authors[0].DisplayName != "João" {
+				t.Errorf("Authors = %+v", doc.Authors)
+			}
+			if len(doc.Posts) != 1 {
+				t.Fatalf("expected 1 post, got %d", len(doc.Posts))
+			}
+			post := doc.Posts[0]
+			if post.ID != 42 || post.Excerpt != "Excerpt" || post.Author != "João" || post.Meta["views"] != "7" {
+				t.Errorf("Post = %+v", post)
+			}
+			if post.Terms["category"][0].ID != 3 {
+				t.Errorf("category term = %+v, want the declared ID", post.Terms["category"])
+			}
+			if post.Extra.Value(wpNamespace, "is_sticky") != "0" {
+				t.Errorf("Extra = %+v, want wp:is_sticky in the 1.2 namespace", post.Extra)
+			}
+		})
+	}
+}
+
+func TestParser_ConcurrentDocuments(t *testing.T) {
+	// authorXML returns a document whose only post is by an author named name
+	authorXML := func(name string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:author><wp:author_login>editor</wp:author_login><wp:author_display_name>` + name + `</wp:author_display_name></wp:author>
+	<item>
+		<title>Post</title>
+		<dc:creator>editor</dc:creator>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_type>post</wp:post_type>
+		<wp:status>publish</wp:status>
+	</item>
+</channel>
+</rss>`
+	}
+
+	// Documents parsed at the same time with one parser must not see each
+	// other's authors
+	parser := NewParser()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		name := fmt.Sprintf("Author %d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				posts, err := parser.Parse(strings.NewReader(authorXML(name)))
+				if err != nil {
+					t.Errorf("Parse() error = %v", err)
+					return
+				}
+				if posts[0].Author != name {
+					t.Errorf("Author = %q, want %q", posts[0].Author, name)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
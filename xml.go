@@ -4,17 +4,37 @@ import "encoding/xml"
 
 // wxr represents the WordPress eXtended RSS structure (internal).
 type wxr struct {
-	XMLName xml.Name `xml:"rss"`
-	Channel channel  `xml:"channel"`
+	XMLName xml.Name
+	Channel channel
 }
 
+// channel is the content of the <channel> element. It is not unmarshalled with
+// struct tags: the item reader decodes items one at a time and the other
+// children with decodeChannelElement, which keeps unmapped ones in Extra.
 type channel struct {
-	Title       string     `xml:"title"`
-	Link        string     `xml:"link"`
-	BaseSiteURL string     `xml:"http://wordpress.org/export/1.2/ base_site_url"`
-	BaseBlogURL string     `xml:"http://wordpress.org/export/1.2/ base_blog_url"`
-	Items       []item     `xml:"item"`
-	Authors     []wpAuthor `xml:"http://wordpress.org/export/1.2/ author"`
+	Title       string
+	Link        string
+	Description string
+	PubDate     string
+	Language    string
+	Generator   string
+	WXRVersion  string
+	BaseSiteURL string
+	BaseBlogURL string
+	Items       []item
+	Authors     []wpAuthor
+	Categories  []wpCategoryTerm
+	Tags        []wpTagTerm
+	Terms       []wpTerm
+	Extra       Nodes
+
+	// NamespaceVersion is the WXR version of the WordPress namespace, detected
+	// while reading; see tokenSource.
+	NamespaceVersion string
+
+	// Diagnostics are the problems worked around in recovery mode and by
+	// mojibake repair.
+	Diagnostics []Diagnostic
 }
 
 type item struct {
@@ -22,6 +42,7 @@ type item struct {
 	Link            string       `xml:"link"`
 	GUID            string       `xml:"guid"`
 	PubDate         string       `xml:"pubDate"`
+	Description     string       `xml:"description"` // empty in WordPress exports
 	DCCreator       string       `xml:"http://purl.org/dc/elements/1.1/ creator"`
 	ContentEncoded  string       `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
 	ExcerptEncoded  string       `xml:"http://wordpress.org/export/1.2/excerpt/ encoded"`
@@ -31,12 +52,15 @@ type item struct {
 	PostModified    string       `xml:"http://wordpress.org/export/1.2/ post_modified"`
 	PostModifiedGMT string       `xml:"http://wordpress.org/export/1.2/ post_modified_gmt"`
 	PostParent      int          `xml:"http://wordpress.org/export/1.2/ post_parent"`
+	MenuOrder       int          `xml:"http://wordpress.org/export/1.2/ menu_order"`
 	PostName        string       `xml:"http://wordpress.org/export/1.2/ post_name"`
 	PostType        string       `xml:"http://wordpress.org/export/1.2/ post_type"`
 	Status          string       `xml:"http://wordpress.org/export/1.2/ status"`
 	AttachmentURL   string       `xml:"http://wordpress.org/export/1.2/ attachment_url"`
 	PostMeta        []postMeta   `xml:"http://wordpress.org/export/1.2/ postmeta"`
 	Categories      []wpCategory `xml:"category"`
+	Comments        []wpComment  `xml:"http://wordpress.org/export/1.2/ comment"`
+	Extra           Nodes        `xml:",any"`
 }
 
 type wpCategory struct {
@@ -45,13 +69,62 @@ type wpCategory struct {
 	NiceName string `xml:"nicename,attr"`
 }
 
+// wpCategoryTerm is a channel-level category definition (wp:category).
+type wpCategoryTerm struct {
+	TermID      int        `xml:"http://wordpress.org/export/1.2/ term_id"`
+	NiceName    string     `xml:"http://wordpress.org/export/1.2/ category_nicename"`
+	Parent      string     `xml:"http://wordpress.org/export/1.2/ category_parent"`
+	Name        string     `xml:"http://wordpress.org/export/1.2/ cat_name"`
+	Description string     `xml:"http://wordpress.org/export/1.2/ category_description"`
+	Meta        []postMeta `xml:"http://wordpress.org/export/1.2/ termmeta"`
+}
+
+// wpTagTerm is a channel-level tag definition (wp:tag).
+type wpTagTerm struct {
+	TermID      int        `xml:"http://wordpress.org/export/1.2/ term_id"`
+	Slug        string     `xml:"http://wordpress.org/export/1.2/ tag_slug"`
+	Name        string     `xml:"http://wordpress.org/export/1.2/ tag_name"`
+	Description string     `xml:"http://wordpress.org/export/1.2/ tag_description"`
+	Meta        []postMeta `xml:"http://wordpress.org/export/1.2/ termmeta"`
+}
+
+// wpTerm is a channel-level term definition of any taxonomy (wp:term).
+type wpTerm struct {
+	TermID      int        `xml:"http://wordpress.org/export/1.2/ term_id"`
+	Taxonomy    string     `xml:"http://wordpress.org/export/1.2/ term_taxonomy"`
+	Slug        string     `xml:"http://wordpress.org/export/1.2/ term_slug"`
+	Parent      string     `xml:"http://wordpress.org/export/1.2/ term_parent"`
+	Name        string     `xml:"http://wordpress.org/export/1.2/ term_name"`
+	Description string     `xml:"http://wordpress.org/export/1.2/ term_description"`
+	Meta        []postMeta `xml:"http://wordpress.org/export/1.2/ termmeta"`
+}
+
 type wpAuthor struct {
 	ID          int    `xml:"http://wordpress.org/export/1.2/ author_id"`
 	Login       string `xml:"http://wordpress.org/export/1.2/ author_login"`
+	Email       string `xml:"http://wordpress.org/export/1.2/ author_email"`
 	DisplayName string `xml:"http://wordpress.org/export/1.2/ author_display_name"`
+	FirstName   string `xml:"http://wordpress.org/export/1.2/ author_first_name"`
+	LastName    string `xml:"http://wordpress.org/export/1.2/ author_last_name"`
 }
 
 type postMeta struct {
 	Key   string `xml:"http://wordpress.org/export/1.2/ meta_key"`
 	Value string `xml:"http://wordpress.org/export/1.2/ meta_value"`
 }
+
+type wpComment struct {
+	ID          int        `xml:"http://wordpress.org/export/1.2/ comment_id"`
+	Author      string     `xml:"http://wordpress.org/export/1.2/ comment_author"`
+	AuthorEmail string     `xml:"http://wordpress.org/export/1.2/ comment_author_email"`
+	AuthorURL   string     `xml:"http://wordpress.org/export/1.2/ comment_author_url"`
+	AuthorIP    string     `xml:"http://wordpress.org/export/1.2/ comment_author_IP"`
+	Date        string     `xml:"http://wordpress.org/export/1.2/ comment_date"`
+	DateGMT     string     `xml:"http://wordpress.org/export/1.2/ comment_date_gmt"`
+	Content     string     `xml:"http://wordpress.org/export/1.2/ comment_content"`
+	Approved    string     `xml:"http://wordpress.org/export/1.2/ comment_approved"`
+	Type        string     `xml:"http://wordpress.org/export/1.2/ comment_type"`
+	Parent      int        `xml:"http://wordpress.org/export/1.2/ comment_parent"`
+	UserID      int        `xml:"http://wordpress.org/export/1.2/ comment_user_id"`
+	Meta        []postMeta `xml:"http://wordpress.org/export/1.2/ commentmeta"`
+}